@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fudanchii/szb/internal/control"
+)
+
+var (
+	ErrClientUsage = errors.New("usage: szb msg \"text\" [--ttl 30s] | szb page <name> [--display name] | szb status")
+)
+
+// runClient runs one of the subcommands that drive a running szb over
+// its control socket.
+func runClient(command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+
+	ttl := flags.Duration("ttl", 0, "")
+	displayName := flags.String("display", "", "")
+
+	positional, err := parseInterspersed(flags, args)
+	if err != nil {
+		return fmt.Errorf("%w (%v)", ErrClientUsage, err)
+	}
+
+	client, err := control.DialUnix(config.socketPath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case command == "msg" && len(positional) > 0:
+		return client.Notify(strings.Join(positional, " "), *ttl)
+	case command == "page" && len(positional) == 1:
+		return client.SwitchPage(*displayName, positional[0])
+	case command == "status" && len(positional) == 0:
+		status, err := client.Status()
+		if err != nil {
+			return err
+		}
+
+		return printStatus(os.Stdout, status)
+	}
+
+	return ErrClientUsage
+}
+
+// parseInterspersed lets flags come after the arguments too, as in
+// `szb msg "deploy done" --ttl 30s`.
+func parseInterspersed(flags *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func printStatus(out io.Writer, status control.Status) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintln(w, "DISPLAY\tTARGET\tSTATE\tPAGE\tBACKLIGHT\tPAGES")
+	for _, ds := range status.Displays {
+		name := ds.Name
+		if name == "" {
+			name = "-"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", name, ds.Target, ds.State, ds.Page, ds.Backlight, strings.Join(ds.Pages, ","))
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, text := range status.Notifications {
+		fmt.Fprintf(out, "notification: %s\n", text)
+	}
+
+	return nil
+}
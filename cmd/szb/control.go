@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/fudanchii/szb/internal/control"
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/lcdimage"
+	"github.com/fudanchii/szb/internal/pages"
+	"github.com/fudanchii/szb/internal/pixel"
+)
+
+// AppHandler is what the control API drives.
+var _ control.Controller = (*AppHandler)(nil)
+
+func (app *AppHandler) Notify(text string, ttl time.Duration) {
+	app.overlay.Post(text, ttl)
+}
+
+func (app *AppHandler) SetLine(name string, idx int, text string) error {
+	page, err := app.findPage(name)
+	if err != nil {
+		return err
+	}
+
+	return page.Pin(idx, text)
+}
+
+func (app *AppHandler) UnsetLine(name string, idx int) error {
+	page, err := app.findPage(name)
+	if err != nil {
+		return err
+	}
+
+	return page.Unpin(idx)
+}
+
+func (app *AppHandler) SwitchPage(name, page string) error {
+	displays, err := app.findDisplays(name)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range displays {
+		if err := inst.pager.Switch(page); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (app *AppHandler) SetBacklight(name string, level int) error {
+	displays, err := app.findDisplays(name)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range displays {
+		if err := inst.schedule.SetOnLevel(level); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Snapshot shows the first display when name is empty.
+func (app *AppHandler) Snapshot(name string) (control.Snapshot, error) {
+	displays, err := app.findDisplays(name)
+	if err != nil {
+		return control.Snapshot{}, err
+	}
+
+	return displays[0].snapshot(), nil
+}
+
+func (app *AppHandler) FrameChanged(name string) (<-chan struct{}, error) {
+	displays, err := app.findDisplays(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return displays[0].frameChanged(), nil
+}
+
+// Input goes to every display when name is empty.
+func (app *AppHandler) Input(name string, event input.Event) error {
+	displays, err := app.findDisplays(name)
+	if err != nil {
+		return err
+	}
+
+	for _, inst := range displays {
+		if err := inst.inject(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Values lists the pages of every display, each once.
+func (app *AppHandler) Values() []control.PageValues {
+	values := []control.PageValues{}
+	seen := map[string]bool{}
+
+	for _, inst := range app.displays {
+		for _, name := range inst.pager.Names() {
+			page, err := inst.pager.Find(name)
+			if err != nil || seen[name] {
+				continue
+			}
+
+			seen[name] = true
+
+			lines := []string{}
+			for idx := range page.Lines {
+				lines = append(lines, page.Line(idx).String())
+			}
+
+			values = append(values, control.PageValues{Page: name, Lines: lines})
+		}
+	}
+
+	return values
+}
+
+func (app *AppHandler) Status() control.Status {
+	status := control.Status{Notifications: []string{}}
+
+	for _, inst := range app.displays {
+		status.Displays = append(status.Displays, control.DisplayStatus{
+			Name:      inst.spec.name,
+			Target:    inst.spec.connectTo,
+			State:     inst.supervisor.State().String(),
+			Page:      inst.pager.Active().Name,
+			Pages:     inst.pager.Names(),
+			Backlight: inst.schedule.Level(time.Now()),
+		})
+	}
+
+	for _, notification := range app.overlay.Pending() {
+		status.Notifications = append(status.Notifications, notification.Text)
+	}
+
+	return status
+}
+
+// findPage looks on the displays first so pages that came in later, like
+// LCDproc screens, are found too.
+func (app *AppHandler) findPage(name string) (*pages.Page, error) {
+	for _, inst := range app.displays {
+		if page, err := inst.pager.Find(name); err == nil {
+			return page, nil
+		}
+	}
+
+	idx := slices.IndexFunc(app.pages, func(page *pages.Page) bool {
+		return page.Name == name
+	})
+	if idx < 0 {
+		return nil, fmt.Errorf("%w: %s", pages.ErrUnknownPage, name)
+	}
+
+	return app.pages[idx], nil
+}
+
+// findDisplays returns the display called name, every display when name
+// is empty.
+func (app *AppHandler) findDisplays(name string) ([]*Instance, error) {
+	if name == "" {
+		return app.displays, nil
+	}
+
+	for _, inst := range app.displays {
+		if inst.spec.name == name {
+			return []*Instance{inst}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", control.ErrUnknownDisplay, name)
+}
+
+// snapshot is what inst last sent to its display.
+func (inst *Instance) snapshot() control.Snapshot {
+	inst.mu.Lock()
+	frame, shown := inst.lastFrame, inst.shown
+	inst.mu.Unlock()
+
+	snapshot := control.Snapshot{
+		Display: inst.spec.name,
+		Page:    inst.pager.Active().Name,
+	}
+
+	if inst.pixels != nil {
+		if shown == nil {
+			shown = pixel.NewFramebuffer(inst.pixels.Width, inst.pixels.Height)
+		}
+
+		snapshot.Cols, snapshot.Rows = inst.pixels.Width, inst.pixels.Height
+		for _, line := range inst.pageLines() {
+			snapshot.Lines = append(snapshot.Lines, line.String())
+		}
+
+		snapshot.WritePNG = func(w io.Writer) error {
+			return shown.WritePNG(w, SNAPSHOT_SCALE)
+		}
+
+		return snapshot
+	}
+
+	geometry := inst.geometry()
+	if len(frame) != geometry.Cols*geometry.Rows {
+		frame = make([]byte, geometry.Cols*geometry.Rows)
+		for idx := range frame {
+			frame[idx] = ' '
+		}
+	}
+
+	snapshot.Cols, snapshot.Rows = geometry.Cols, geometry.Rows
+	for _, row := range display.FrameRows(frame, geometry.Cols, geometry.Rows) {
+		snapshot.Lines = append(snapshot.Lines, display.DecodeLCDCharMap(row))
+	}
+
+	rom := inst.supervisor.Capabilities().ROM
+	snapshot.WritePNG = func(w io.Writer) error {
+		renderer := lcdimage.NewRenderer(geometry.Cols, geometry.Rows)
+		renderer.ROM = rom
+
+		return renderer.WritePNG(w, frame)
+	}
+
+	return snapshot
+}
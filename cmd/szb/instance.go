@@ -0,0 +1,833 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fudanchii/szb/internal/backlight"
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/driver"
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/menu"
+	"github.com/fudanchii/szb/internal/notify"
+	"github.com/fudanchii/szb/internal/pages"
+	"github.com/fudanchii/szb/internal/pixel"
+	"github.com/fudanchii/szb/internal/protocol"
+	"github.com/fudanchii/szb/internal/recording"
+	"github.com/fudanchii/szb/internal/trace"
+	"github.com/fudanchii/szb/internal/transport"
+)
+
+var (
+	ErrInvalidDisplay = errors.New("config: error parsing display, please specify name:key=value;... with the keys listed for -display (e.g. desk:c=/dev/ttyUSB0;o=t,em,em,em;size=16x2)")
+	ErrInvalidPace    = errors.New("config: error, unknown pace, use prompt, rate or change")
+	ErrInputQueueFull = errors.New("input: error, too many events waiting for the display")
+	ErrSilentCredit   = errors.New("config: error, credit flow needs the szb firmware, use none or rtscts")
+)
+
+const (
+	// PACE_PROMPT sends a frame whenever the device prompts for one, the
+	// other paces push frames without waiting: PACE_RATE at a fixed rate
+	// and PACE_CHANGE only when the frame differs from the last one sent.
+	PACE_PROMPT = "prompt"
+	PACE_RATE   = "rate"
+	PACE_CHANGE = "change"
+
+	INPUT_QUEUE = 16
+
+	// DRIVER_SSD1306 drives a pixel display through firmware that speaks
+	// the szb protocol with fb: commands, pages are drawn instead of
+	// laid out as characters.
+	DRIVER_SSD1306 = "ssd1306"
+	SNAPSHOT_SCALE = 4
+)
+
+// displayList collects every -display flag given.
+type displayList []string
+
+func (dl *displayList) String() string {
+	return strings.Join(*dl, " ")
+}
+
+func (dl *displayList) Set(value string) error {
+	*dl = append(*dl, value)
+	return nil
+}
+
+// displaySpec is what one display shows and how to reach it, keys left out
+// of a -display flag take the value of the matching top level flag.
+type displaySpec struct {
+	name      string
+	connectTo string
+	line      transport.SerialLine
+	driver    string
+	overflow  string
+	startPage string
+	pages     []string
+	// sizes are the text sizes of the page lines on pixel displays.
+	sizes []int
+
+	// geometry overrides what the device reports when set. Pixel displays
+	// take the size as pixels instead, their geometry follows from it.
+	geometry display.Geometry
+	pixels   display.Geometry
+
+	pace         string
+	fps          int
+	flow         string
+	writeTimeout time.Duration
+}
+
+func defaultDisplaySpec() (displaySpec, error) {
+	line, err := serialLine()
+	if err != nil {
+		return displaySpec{}, err
+	}
+
+	sizes, err := pixel.ParseSizes(config.sizes)
+	if err != nil {
+		return displaySpec{}, err
+	}
+
+	return displaySpec{
+		sizes:        sizes,
+		connectTo:    config.connectTo,
+		line:         line,
+		driver:       config.driver,
+		overflow:     config.overflowStyle,
+		startPage:    config.startPage,
+		pace:         config.pace,
+		fps:          config.fps,
+		flow:         config.flow,
+		writeTimeout: config.writeTimeout,
+	}, nil
+}
+
+// serialLine is the serial setup given by the top level flags.
+func serialLine() (transport.SerialLine, error) {
+	line := transport.DefaultSerialLine(config.baudRate)
+	line.ReadTimeout = config.readTimeout
+	line.DTR = config.dtr
+	line.RTS = config.rts
+	line.Reset = config.resetBoard
+	line.Settle = config.settleDelay
+
+	return line, line.SetFraming(config.framing)
+}
+
+// parseDisplaySpec reads `name:key=value;key=value`, for example
+//
+//	desk:c=/dev/ttyUSB0;o=t,em,em,em;pages=system;size=16x2
+func parseDisplaySpec(spec string) (displaySpec, error) {
+	ds, err := defaultDisplaySpec()
+	if err != nil {
+		return ds, err
+	}
+
+	name, fields, ok := strings.Cut(spec, ":")
+	if !ok || name == "" {
+		return ds, ErrInvalidDisplay
+	}
+
+	ds.name = name
+	pageGiven := false
+
+	for _, field := range strings.Split(fields, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(field), "=")
+		if !ok {
+			return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+		}
+
+		switch key {
+		case "c":
+			ds.connectTo = value
+		case "b":
+			baudRate, err := strconv.Atoi(value)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			ds.line.BaudRate = baudRate
+		case "line":
+			if err := ds.line.SetFraming(value); err != nil {
+				return ds, err
+			}
+		case "dtr", "rts", "reset":
+			level, err := strconv.ParseBool(value)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			switch key {
+			case "dtr":
+				ds.line.DTR = level
+			case "rts":
+				ds.line.RTS = level
+			case "reset":
+				ds.line.Reset = level
+			}
+		case "settle", "rtimeout":
+			delay, err := time.ParseDuration(value)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			if key == "settle" {
+				ds.line.Settle = delay
+			} else {
+				ds.line.ReadTimeout = delay
+			}
+		case "driver":
+			ds.driver = value
+		case "sizes":
+			ds.sizes, err = pixel.ParseSizes(value)
+			if err != nil {
+				return ds, err
+			}
+		case "o":
+			ds.overflow = value
+		case "page":
+			ds.startPage = value
+			pageGiven = true
+		case "pages":
+			ds.pages = strings.Split(value, ",")
+		case "size":
+			cols, rows, ok := strings.Cut(value, "x")
+			if !ok {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			var err error
+
+			ds.geometry.Cols, err = strconv.Atoi(cols)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			ds.geometry.Rows, err = strconv.Atoi(rows)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+		case "pace":
+			ds.pace = value
+		case "fps":
+			fps, err := strconv.Atoi(value)
+			if err != nil || fps <= 0 {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			ds.fps = fps
+		case "flow":
+			ds.flow = value
+		case "wtimeout":
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return ds, fmt.Errorf("%w: %s", ErrInvalidDisplay, field)
+			}
+
+			ds.writeTimeout = timeout
+		default:
+			return ds, fmt.Errorf("%w: unknown key %s", ErrInvalidDisplay, key)
+		}
+	}
+
+	if ds.driver == DRIVER_SSD1306 {
+		ds.pixels, ds.geometry = ds.geometry, display.Geometry{}
+	}
+
+	if !pageGiven && len(ds.pages) > 0 && !slices.Contains(ds.pages, ds.startPage) {
+		ds.startPage = ds.pages[0]
+	}
+
+	return ds, nil
+}
+
+// Instance is one display with its own connection, layout and styles.
+// The line sources on its pages are shared with every other display.
+type Instance struct {
+	spec displaySpec
+
+	tty        io.ReadWriteCloser
+	supervisor *transport.Supervisor
+	driver     driver.Driver
+	recorder   *recording.Recorder
+	buffer     *display.Buffer
+	scanner    *bufio.Scanner
+	pending    [][]byte
+
+	// mu guards lastFrame and shown, the control API reads them. changed
+	// is closed, and replaced, whenever either of them changes.
+	mu         sync.Mutex
+	changed    chan struct{}
+	lastFrame  []byte
+	schedule   *backlight.Schedule
+	pager      *pages.Pager
+	overlay    *notify.Overlay
+	dispatcher *input.Dispatcher
+
+	menu      *menu.Menu
+	menuShown bool
+	style     display.OverflowStyle
+	menuStyle display.OverflowStyle
+
+	// events carries input to the loop from the reader in push mode, and
+	// from the control API.
+	events chan input.Event
+
+	// silent displays never prompt, what they show is sent again after
+	// reconnecting, reconnected tells the loop to.
+	silent      bool
+	reconnected atomic.Bool
+
+	// pixels draws the pages of pixel displays, shown is what the display
+	// has been sent and refresh the next chunk to send again when
+	// nothing changed.
+	pixels     *pixel.Renderer
+	menuPixels *pixel.Renderer
+	shown      *pixel.Framebuffer
+	refresh    int
+}
+
+func newInstance(app *AppHandler, spec displaySpec, done <-chan struct{}) (*Instance, error) {
+	if !slices.Contains([]string{PACE_PROMPT, PACE_RATE, PACE_CHANGE}, spec.pace) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidPace, spec.pace)
+	}
+
+	if spec.fps <= 0 {
+		return nil, fmt.Errorf("%w: fps=%d", ErrInvalidDisplay, spec.fps)
+	}
+
+	flow, err := transport.ParseFlow(spec.flow)
+	if err != nil {
+		return nil, err
+	}
+
+	drv, err := newDriver(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nothing would ever ask for a frame, displays that never prompt go
+	// by change instead.
+	_, prompts := drv.(driver.Szb)
+	if !prompts && spec.pace == PACE_PROMPT {
+		spec.pace = PACE_CHANGE
+	}
+
+	if !prompts && flow == transport.FlowCredit {
+		return nil, fmt.Errorf("%w: %s", ErrSilentCredit, drv)
+	}
+
+	conn, err := openTransport(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.tracePath != "" {
+		conn, err = trace.Open(conn, instancePath(config.tracePath, spec.name))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	supervisor := transport.NewSupervisor(conn, done)
+	supervisor.Silent = !prompts
+	supervisor.Init = drv.Init()
+	supervisor.Handshake = !config.legacyProtocol
+	supervisor.Framed = config.framedProtocol
+	supervisor.Push = spec.pace != PACE_PROMPT
+	supervisor.Flow = flow
+	supervisor.WriteTimeout = spec.writeTimeout
+
+	inst := &Instance{
+		spec:       spec,
+		tty:        supervisor,
+		supervisor: supervisor,
+		driver:     drv,
+		silent:     !prompts,
+		overlay:    app.overlay,
+		events:     make(chan input.Event, INPUT_QUEUE),
+		changed:    make(chan struct{}),
+	}
+
+	supervisor.OnStateChange = func(state transport.State, err error) {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s (%v)\n", conn, state, err)
+			return
+		}
+
+		if state == transport.StateConnected {
+			inst.reconnected.Store(true)
+		}
+
+		fmt.Fprintf(os.Stderr, "%s: %s\n", conn, state)
+	}
+
+	if spec.driver == DRIVER_SSD1306 {
+		width, height := pixel.SSD1306Width, pixel.SSD1306Height
+		if spec.pixels.Cols > 0 && spec.pixels.Rows > 0 {
+			width, height = spec.pixels.Cols, spec.pixels.Rows
+		}
+
+		inst.pixels = pixel.NewRenderer(width, height, spec.sizes)
+		inst.menuPixels = pixel.NewRenderer(width, height, slices.Repeat([]int{1}, height/pixel.CellHeight))
+	}
+
+	inst.style, err = newOverflowStyle(spec.overflow)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.menuStyle, err = newOverflowStyle("t,t,t,t")
+	if err != nil {
+		return nil, err
+	}
+
+	inst.buffer = display.NewBuffer(inst.style)
+	inst.buffer.SetGeometry(inst.geometry())
+	inst.buffer.SetContrast(config.contrastLevel)
+
+	inst.schedule, err = backlight.NewSchedule(
+		config.nightSchedule,
+		app.datetime.Timezone(),
+		config.backlightLevel,
+		config.dimLevel,
+		config.idleDimAfter,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.pager, err = app.newPager(spec.pages)
+	if err != nil {
+		return nil, err
+	}
+
+	// A page picked from the menu may be gone by the next start, e.g. an
+	// LCDproc screen, the first page does then.
+	if err := inst.pager.Switch(spec.startPage); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v, showing %s\n", inst, err, inst.pager.Active().Name)
+	}
+
+	inst.menu = newMenu(app, inst)
+	inst.dispatcher = newDispatcher(inst.pager, inst.overlay, inst.schedule, inst.menu, app.countdown)
+
+	return inst, nil
+}
+
+// instancePath tells the files of named displays apart by adding the
+// name before the extension.
+func instancePath(path, name string) string {
+	if name == "" {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, ext) + "-" + name + ext
+}
+
+func (inst *Instance) String() string {
+	if inst.spec.name == "" {
+		return inst.spec.connectTo
+	}
+
+	return inst.spec.name
+}
+
+// geometry is the size in characters, pixel displays fit as many
+// characters of the smallest text size as there is room for.
+func (inst *Instance) geometry() display.Geometry {
+	if inst.pixels != nil {
+		return display.Geometry{Cols: inst.pixels.Width / pixel.CellWidth, Rows: inst.pixels.Height / pixel.CellHeight}
+	}
+
+	if inst.spec.geometry.Cols > 0 && inst.spec.geometry.Rows > 0 {
+		return inst.spec.geometry
+	}
+
+	caps := inst.supervisor.Capabilities()
+
+	return display.Geometry{Cols: caps.Cols, Rows: caps.Rows}
+}
+
+// persist keeps a setting of this display in the settings file. Only the
+// display set up by the top level flags has its settings there.
+func (inst *Instance) persist(name, value string) error {
+	if inst.spec.name != "" {
+		return nil
+	}
+
+	return persist(name, value)
+}
+
+// connect waits for the device, the supervisor retries with backoff
+// until done is closed. Recording starts once the size is known.
+func (inst *Instance) connect() error {
+	if _, err := inst.supervisor.Connect(); err != nil {
+		return err
+	}
+
+	inst.buffer.SetGeometry(inst.geometry())
+
+	if config.recordPath != "" {
+		recorder, err := openRecorder(inst.driver, inst.geometry(), instancePath(config.recordPath, inst.spec.name))
+		if err != nil {
+			return err
+		}
+
+		inst.recorder = recorder
+		inst.driver = recorder
+	}
+
+	inst.scanner = bufio.NewScanner(inst.tty)
+	inst.scanner.Split(bufio.ScanWords)
+
+	return nil
+}
+
+// run connects to the display and drives it until done is closed.
+func (inst *Instance) run(done <-chan struct{}) {
+	// The supervisor gives up with EOF when asked to stop while waiting,
+	// that is a shutdown like any other.
+	if err := inst.connect(); err != nil {
+		if !errors.Is(err, io.EOF) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", inst, err)
+		}
+
+		return
+	}
+
+	if inst.spec.pace != PACE_PROMPT {
+		go inst.readEvents(done)
+	}
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		if inst.spec.pace == PACE_PROMPT {
+			inst.step()
+		} else {
+			inst.push()
+		}
+	}
+}
+
+// readEvents keeps reading the device in push mode, where nothing waits
+// for a prompt, and queues input for the loop to dispatch.
+func (inst *Instance) readEvents(done <-chan struct{}) {
+	for inst.scanner.Scan() {
+		event, ok := input.Parse(inst.scanner.Text())
+		if !ok {
+			continue
+		}
+
+		select {
+		case inst.events <- event:
+		case <-done:
+			return
+		}
+	}
+}
+
+func (inst *Instance) pageLines() []fmt.Stringer {
+	page := inst.pager.Active()
+	lines := []fmt.Stringer{page.Line(0), page.Line(1), page.Line(2), page.Line(3)}
+
+	// Notifications take over the second line until dismissed.
+	if text, ok := inst.overlay.Current(); ok {
+		lines[1] = pages.Text(text)
+	}
+
+	return lines
+}
+
+func (inst *Instance) setPageLines() {
+	lines := inst.pageLines()
+
+	inst.buffer.SetLine1(lines[0])
+	inst.buffer.SetLine2(lines[1])
+	inst.buffer.SetLine3(lines[2])
+	inst.buffer.SetLine4(lines[3])
+}
+
+// queuePixels draws what a pixel display shows next and queues the
+// chunks that changed. Nothing is drawn while earlier chunks still wait
+// to go out.
+func (inst *Instance) queuePixels() {
+	if len(inst.pending) > 0 {
+		return
+	}
+
+	var fb *pixel.Framebuffer
+
+	if inst.menuShown {
+		lines := []fmt.Stringer{}
+		for _, line := range inst.menu.Render(inst.menuPixels.Width/pixel.CellWidth, len(inst.menuPixels.Sizes)) {
+			lines = append(lines, pages.Text(line))
+		}
+
+		fb = inst.menuPixels.Render(lines)
+	} else {
+		fb = inst.pixels.Render(inst.pageLines())
+	}
+
+	for _, chunk := range fb.Chunks(inst.shown, pixel.DefaultChunk) {
+		inst.pending = append(inst.pending, protocol.FramebufferCommand(chunk.Page, chunk.Col, chunk.Data))
+	}
+
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	if inst.shown == nil || len(inst.pending) > 0 {
+		inst.notifyChanged()
+	}
+
+	inst.shown = fb
+}
+
+// refreshCommand sends one chunk of what is shown again, in turn, so a
+// display that lost its memory catches up while nothing changes.
+func (inst *Instance) refreshCommand() []byte {
+	chunks := inst.shown.Chunks(nil, pixel.DefaultChunk)
+	chunk := chunks[inst.refresh%len(chunks)]
+	inst.refresh++
+
+	return protocol.FramebufferCommand(chunk.Page, chunk.Col, chunk.Data)
+}
+
+func (inst *Instance) setMenuLines(geometry display.Geometry) {
+	var lines [4]pages.Text
+	for idx, line := range inst.menu.Render(geometry.Cols, min(geometry.Rows, len(lines))) {
+		lines[idx] = pages.Text(line)
+	}
+
+	inst.buffer.SetLine1(lines[0])
+	inst.buffer.SetLine2(lines[1])
+	inst.buffer.SetLine3(lines[2])
+	inst.buffer.SetLine4(lines[3])
+}
+
+// waitPrompt reads device tokens up to the next prompt, input events met
+// on the way are dispatched.
+func (inst *Instance) waitPrompt() bool {
+	for inst.scanner.Scan() {
+		token := inst.scanner.Text()
+		if token == protocol.CmdPrompt {
+			return true
+		}
+
+		if event, ok := input.Parse(token); ok {
+			inst.dispatcher.Dispatch(event)
+		}
+	}
+
+	return false
+}
+
+func frameDelay(caps protocol.Capabilities) time.Duration {
+	return max(DISPLAY_RATE_MS*time.Millisecond, time.Second/time.Duration(caps.MaxRate))
+}
+
+// update lays out what goes on the display next and queues lighting
+// changes.
+func (inst *Instance) update(caps protocol.Capabilities) {
+	geometry := inst.geometry()
+	inst.buffer.SetGeometry(geometry)
+
+	if open := inst.menu.IsOpen(); open != inst.menuShown {
+		inst.menuShown = open
+
+		// The menu is laid out to fit, so it is shown trimmed whatever
+		// style the pages use.
+		if open {
+			inst.buffer.SetStyle(inst.menuStyle)
+		} else {
+			inst.buffer.SetStyle(inst.style)
+		}
+	}
+
+	if inst.menuShown {
+		inst.setMenuLines(geometry)
+	} else {
+		inst.setPageLines()
+	}
+
+	if inst.pixels != nil {
+		inst.queuePixels()
+	}
+
+	inst.buffer.SetBacklight(inst.schedule.Level(time.Now()))
+
+	if lighting, changed := inst.buffer.LightingChanged(); changed && (caps.Backlight || inst.silent) {
+		inst.pending = append(
+			inst.pending,
+			inst.driver.Backlight(lighting.Backlight),
+			inst.driver.Contrast(lighting.Contrast),
+		)
+	}
+}
+
+func (inst *Instance) step() {
+	for len(inst.events) > 0 {
+		inst.dispatcher.Dispatch(<-inst.events)
+	}
+
+	caps := inst.supervisor.Capabilities()
+	inst.update(caps)
+
+	if inst.waitPrompt() {
+		// Control commands take their own prompt, frames resume after them.
+		if len(inst.pending) > 0 {
+			inst.tty.Write(inst.pending[0])
+			inst.pending = inst.pending[1:]
+
+			return
+		}
+
+		if inst.pixels != nil {
+			inst.tty.Write(inst.refreshCommand())
+			time.Sleep(frameDelay(caps))
+
+			return
+		}
+
+		frame := inst.buffer.NextRender()
+		inst.setLastFrame(frame)
+
+		inst.tty.Write(inst.driver.Frame(frame))
+
+		time.Sleep(frameDelay(caps))
+	}
+}
+
+func (inst *Instance) setLastFrame(frame []byte) {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	if !bytes.Equal(frame, inst.lastFrame) {
+		inst.notifyChanged()
+	}
+
+	inst.lastFrame = slices.Clone(frame)
+}
+
+// notifyChanged wakes everyone waiting on a new frame, inst.mu is held.
+func (inst *Instance) notifyChanged() {
+	close(inst.changed)
+	inst.changed = make(chan struct{})
+}
+
+// frameChanged is closed once the display shows something new.
+func (inst *Instance) frameChanged() <-chan struct{} {
+	inst.mu.Lock()
+	defer inst.mu.Unlock()
+
+	return inst.changed
+}
+
+// inject queues event as if it came from the device.
+func (inst *Instance) inject(event input.Event) error {
+	select {
+	case inst.events <- event:
+		return nil
+	default:
+		return ErrInputQueueFull
+	}
+}
+
+// push sends without waiting for a prompt, the flow control of the
+// connection keeps the device from being overrun.
+func (inst *Instance) push() {
+	for len(inst.events) > 0 {
+		inst.dispatcher.Dispatch(<-inst.events)
+	}
+
+	// The supervisor restores the lighting of the szb firmware, silent
+	// displays get the frame and lighting sent again instead.
+	if inst.silent && inst.reconnected.Swap(false) {
+		inst.mu.Lock()
+		inst.lastFrame = nil
+		inst.mu.Unlock()
+
+		inst.buffer.RefreshLighting()
+	}
+
+	caps := inst.supervisor.Capabilities()
+	inst.update(caps)
+
+	sent := len(inst.pending) > 0
+	for _, cmd := range inst.pending {
+		if _, err := inst.tty.Write(cmd); err != nil {
+			// Chunks went missing, draw it all again.
+			inst.mu.Lock()
+			inst.shown = nil
+			inst.mu.Unlock()
+		}
+	}
+	inst.pending = nil
+
+	frame := inst.buffer.NextRender()
+	switch {
+	case inst.pixels != nil:
+		if inst.spec.pace == PACE_RATE && !sent {
+			inst.tty.Write(inst.refreshCommand())
+		}
+	case inst.spec.pace == PACE_RATE || !bytes.Equal(frame, inst.lastFrame):
+		if _, err := inst.tty.Write(inst.driver.Frame(frame)); err == nil {
+			inst.setLastFrame(frame)
+		}
+	}
+
+	delay := DISPLAY_RATE_MS * time.Millisecond
+	if inst.spec.pace == PACE_RATE {
+		delay = time.Second / time.Duration(inst.spec.fps)
+	}
+
+	time.Sleep(delay)
+}
+
+// close blanks the display and lets go of it, saving a snapshot of the
+// last frame when asked to.
+func (inst *Instance) close() error {
+	// Closing the supervisor closes the trace as well.
+	defer inst.tty.Close()
+
+	inst.tty.Write(inst.driver.Clear())
+
+	if inst.recorder != nil {
+		if err := inst.recorder.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", inst, err)
+		}
+	}
+
+	if config.snapshotPath != "" && inst.shown != nil {
+		return savePixelSnapshot(instancePath(config.snapshotPath, inst.spec.name), inst.shown)
+	}
+
+	if config.snapshotPath != "" && inst.lastFrame != nil {
+		return saveSnapshot(
+			instancePath(config.snapshotPath, inst.spec.name),
+			inst.lastFrame,
+			inst.geometry(),
+			inst.supervisor.Capabilities(),
+		)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/backlight"
+	"github.com/fudanchii/szb/internal/control"
+	"github.com/fudanchii/szb/internal/kickstart"
+	"github.com/fudanchii/szb/internal/lcdsim"
+	"github.com/fudanchii/szb/internal/transport"
+)
+
+// waitScreen polls the device until row shows a line starting with prefix.
+func waitScreen(t *testing.T, pty *lcdsim.PTY, row int, prefix string) {
+	t.Helper()
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		if strings.HasPrefix(string(pty.Screen()[row]), prefix) {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("row %d shows %q, want it to start with %q", row, pty.Screen()[row], prefix)
+}
+
+func TestEndToEnd(t *testing.T) {
+	pty, err := lcdsim.OpenPTY(LCD_COLS, LCD_ROWS, 5*time.Millisecond, lcdsim.Discard)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer pty.Close()
+
+	config.connectTo = pty.Path
+	config.configPath = ""
+	// The default style, notifications have to show with it too.
+	config.overflowStyle = "wrap"
+	config.startPage = "overview"
+	config.dayOfWeekDisplayPeriod = 0
+	config.socketPath = filepath.Join(t.TempDir(), "szb.sock")
+
+	done := make(chan struct{})
+	kctx := kickstart.NewContext[AppHandler](done)
+
+	if err := setup(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := start(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	looped := make(chan error)
+	go func() {
+		looped <- mainOperation(kctx)
+	}()
+
+	waitScreen(t, pty, 0, time.Now().UTC().Format("2006-01-02"))
+
+	// Button 1 moves on to the system page.
+	pty.Send("btn:1:down")
+	pty.Send("btn:1:up")
+	waitScreen(t, pty, 1, "cpu ")
+	waitScreen(t, pty, 2, "mem ")
+
+	// Scripts post notifications through the control socket.
+	if err := runClient("msg", []string{"deploy done", "--ttl", "30s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	waitScreen(t, pty, 1, "deploy done")
+
+	close(done)
+
+	if err := <-looped; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := shutdown(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// The clear goes through the terminal on its own time.
+	for row := range LCD_ROWS {
+		waitScreen(t, pty, row, strings.Repeat(" ", LCD_COLS))
+	}
+}
+
+func TestSetupWithoutDevice(t *testing.T) {
+	config.connectTo = filepath.Join(t.TempDir(), "ttyACM0")
+	config.configPath = ""
+	// Saved from the menu while an LCDproc client was around.
+	config.startPage = "lcdproc:load"
+	config.socketPath = filepath.Join(t.TempDir(), "szb.sock")
+
+	done := make(chan struct{})
+	kctx := kickstart.NewContext[AppHandler](done)
+
+	// A display that is not there holds nothing else back.
+	if err := setup(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := start(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := control.DialUnix(config.socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := client.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state := status.Displays[0].State; state == transport.StateConnected.String() {
+		t.Errorf("display is %s, want it still waiting", state)
+	}
+
+	if page := status.Displays[0].Page; page != "overview" {
+		t.Errorf("display shows %s, want the first page", page)
+	}
+
+	close(done)
+
+	if err := shutdown(kctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetupLevels(t *testing.T) {
+	defer func(backlightLevel, contrastLevel int) {
+		config.backlightLevel, config.contrastLevel = backlightLevel, contrastLevel
+	}(config.backlightLevel, config.contrastLevel)
+
+	config.backlightLevel = 300
+	if err := setup(kickstart.NewContext[AppHandler](make(chan struct{}))); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("setup with -backlight 300 = %v, want ErrInvalidLevel", err)
+	}
+
+	config.backlightLevel, config.contrastLevel = backlight.LevelOn, -1
+	if err := setup(kickstart.NewContext[AppHandler](make(chan struct{}))); !errors.Is(err, ErrInvalidLevel) {
+		t.Errorf("setup with -contrast -1 = %v, want ErrInvalidLevel", err)
+	}
+}
+
+func TestSetupSocketInUse(t *testing.T) {
+	config.connectTo = filepath.Join(t.TempDir(), "ttyACM0")
+	config.configPath = ""
+	config.socketPath = filepath.Join(t.TempDir(), "szb.sock")
+
+	other, err := control.ListenUnix(config.socketPath, &AppHandler{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Shutdown(context.Background())
+
+	done := make(chan struct{})
+	kctx := kickstart.NewContext[AppHandler](done)
+
+	// Another szb on the socket leaves this one driving its displays.
+	if err := setup(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if kctx.AppHandler.socket != nil {
+		t.Error("took over the socket of another szb")
+	}
+
+	if err := start(kctx); err != nil {
+		t.Fatal(err)
+	}
+
+	close(done)
+
+	if err := shutdown(kctx); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/menu"
+)
+
+var (
+	timezoneOptions = []menu.Option{
+		{Label: "UTC", Value: "UTC"},
+		{Label: "Tokyo", Value: "Asia/Tokyo"},
+		{Label: "Jakarta", Value: "Asia/Jakarta"},
+		{Label: "Singapore", Value: "Asia/Singapore"},
+		{Label: "London", Value: "Europe/London"},
+		{Label: "Berlin", Value: "Europe/Berlin"},
+		{Label: "New York", Value: "America/New_York"},
+		{Label: "Los Angeles", Value: "America/Los_Angeles"},
+	}
+
+	overflowOptions = []menu.Option{
+		{Label: "wrap", Value: "wrap"},
+		{Label: "marquee", Value: "t,em,em,em"},
+		{Label: "cycle", Value: "t,cm,cm,cm"},
+		{Label: "trim", Value: "t,t,t,t"},
+	}
+
+	backlightOptions = []menu.Option{
+		{Label: "12%", Value: "32"},
+		{Label: "25%", Value: "64"},
+		{Label: "50%", Value: "128"},
+		{Label: "75%", Value: "192"},
+		{Label: "100%", Value: "255"},
+	}
+)
+
+// newMenu builds the menu of inst. Timezone and location are shared by
+// every display, the rest only changes inst.
+func newMenu(app *AppHandler, inst *Instance) *menu.Menu {
+	return menu.New(
+		menu.Item{
+			Label: "Page",
+			Options: func() []menu.Option {
+				options := []menu.Option{}
+				for _, name := range inst.pager.Names() {
+					label := name
+					if page, err := inst.pager.Find(name); err == nil {
+						label = page.Label()
+					}
+
+					options = append(options, menu.Option{Label: label, Value: name})
+				}
+
+				return options
+			},
+			Current: func() string {
+				return inst.pager.Active().Name
+			},
+			Apply: func(value string) error {
+				if err := inst.pager.Switch(value); err != nil {
+					return err
+				}
+
+				return inst.persist("page", value)
+			},
+		},
+		menu.Item{
+			Label: "Timezone",
+			Options: func() []menu.Option {
+				return withCurrent(timezoneOptions, app.datetime.Timezone())
+			},
+			Current: app.datetime.Timezone,
+			Apply: func(value string) error {
+				if err := app.SetTimezone(value); err != nil {
+					return err
+				}
+
+				return persist("t", value)
+			},
+		},
+		menu.Item{
+			Label: "Overflow",
+			Options: func() []menu.Option {
+				return withCurrent(overflowOptions, inst.spec.overflow)
+			},
+			Current: func() string {
+				return inst.spec.overflow
+			},
+			Apply: func(value string) error {
+				style, err := newOverflowStyle(value)
+				if err != nil {
+					return err
+				}
+
+				// Put to use once the menu closes.
+				inst.style = style
+				inst.spec.overflow = value
+
+				return inst.persist("o", value)
+			},
+		},
+		menu.Item{
+			Label: "Backlight",
+			Options: func() []menu.Option {
+				return withCurrent(backlightOptions, strconv.Itoa(inst.schedule.OnLevel()))
+			},
+			Current: func() string {
+				return strconv.Itoa(inst.schedule.OnLevel())
+			},
+			Apply: func(value string) error {
+				level, err := strconv.Atoi(value)
+				if err != nil {
+					return err
+				}
+
+				if err := inst.schedule.SetOnLevel(level); err != nil {
+					return err
+				}
+
+				return inst.persist("backlight", value)
+			},
+		},
+		menu.Item{
+			Label: "Location",
+			Options: func() []menu.Option {
+				return withCurrent(parseLocations(config.locations), app.Location())
+			},
+			Current: app.Location,
+			Apply: func(value string) error {
+				if err := app.SetLocation(value); err != nil {
+					return err
+				}
+
+				return persist("x", value)
+			},
+		},
+	)
+}
+
+// menuInput drives the menu while it is open, keeping events from the page
+// bindings. A long press on the menu button opens it, short presses pick
+// once the button is let go so holding it to go back picks nothing.
+func menuInput(mainMenu *menu.Menu, event input.Event) bool {
+	if !mainMenu.IsOpen() {
+		btn, ok := event.(input.ButtonEvent)
+		if ok && btn.Button == BUTTON_MENU && btn.Action == input.ButtonLong {
+			mainMenu.Open()
+			return true
+		}
+
+		return false
+	}
+
+	switch ev := event.(type) {
+	case input.EncoderEvent:
+		mainMenu.Move(ev.Delta)
+	case input.ButtonEvent:
+		switch {
+		case ev.Action == input.ButtonLong:
+			mainMenu.Back()
+		case ev.Action == input.ButtonPress && ev.Button == BUTTON_PAGE:
+			mainMenu.Move(1)
+		case ev.Action == input.ButtonPress && ev.Button == BUTTON_MENU:
+			if err := mainMenu.Select(); err != nil {
+				fmt.Fprintf(os.Stderr, "menu: %v\n", err)
+			}
+		}
+	}
+
+	return true
+}
+
+// withCurrent makes sure the value in use is one of the options.
+func withCurrent(options []menu.Option, current string) []menu.Option {
+	if slices.ContainsFunc(options, func(opt menu.Option) bool { return opt.Value == current }) {
+		return options
+	}
+
+	return append(slices.Clone(options), menu.Option{Label: current, Value: current})
+}
+
+// parseLocations reads `Name=lat,long;Name=lat,long`, malformed entries
+// are skipped.
+func parseLocations(spec string) []menu.Option {
+	options := []menu.Option{}
+
+	for _, entry := range strings.Split(spec, ";") {
+		name, coordinate, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			continue
+		}
+
+		if _, err := parseCoordinates(coordinate); err != nil {
+			continue
+		}
+
+		options = append(options, menu.Option{Label: name, Value: coordinate})
+	}
+
+	return options
+}
+
+// persist keeps a setting changed from the menu in the settings file.
+func persist(name, value string) error {
+	if settingsFile == nil {
+		return nil
+	}
+
+	settingsFile.Set(name, value)
+
+	return settingsFile.Save()
+}
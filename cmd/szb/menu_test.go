@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/backlight"
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/menu"
+	"github.com/fudanchii/szb/internal/notify"
+	"github.com/fudanchii/szb/internal/pages"
+	"github.com/fudanchii/szb/internal/timer"
+)
+
+func TestButtons(t *testing.T) {
+	pager := pages.NewPager(&pages.Page{Name: "overview"}, &pages.Page{Name: "system"})
+	overlay := notify.NewOverlay()
+	overlay.Post("door open", 0)
+
+	schedule, err := backlight.NewSchedule("", "UTC", backlight.LevelOn, 48, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied := []string{}
+	mainMenu := menu.New(menu.Item{
+		Label: "Page",
+		Options: func() []menu.Option {
+			return []menu.Option{{Label: "overview", Value: "overview"}, {Label: "system", Value: "system"}}
+		},
+		Current: func() string { return pager.Active().Name },
+		Apply: func(value string) error {
+			applied = append(applied, value)
+			return nil
+		},
+	})
+
+	dispatcher := newDispatcher(pager, overlay, schedule, mainMenu, timer.NewCountdown(time.Minute, nil))
+
+	// press sends what the device does, down first and long once held.
+	press := func(button int, long bool) {
+		dispatcher.Dispatch(input.ButtonEvent{Button: button, Action: input.ButtonDown})
+		if long {
+			dispatcher.Dispatch(input.ButtonEvent{Button: button, Action: input.ButtonLong})
+		}
+		dispatcher.Dispatch(input.ButtonEvent{Button: button, Action: input.ButtonUp})
+	}
+
+	press(BUTTON_PAGE, true)
+	if page := pager.Active().Name; page != "system" {
+		t.Errorf("holding the page button shows %s, want the previous page", page)
+	}
+
+	press(BUTTON_PAGE, false)
+	if page := pager.Active().Name; page != "overview" {
+		t.Errorf("pressing the page button shows %s, want the next page", page)
+	}
+
+	press(BUTTON_MENU, true)
+	if !mainMenu.IsOpen() {
+		t.Fatal("holding the menu button left the menu closed")
+	}
+
+	if _, ok := overlay.Current(); !ok {
+		t.Error("opening the menu dismissed the notification")
+	}
+
+	// Pick an option, then hold to go back without applying it.
+	press(BUTTON_MENU, false)
+	press(BUTTON_PAGE, false)
+	press(BUTTON_MENU, true)
+	press(BUTTON_MENU, true)
+
+	if mainMenu.IsOpen() || len(applied) > 0 {
+		t.Errorf("menu open %v after going back, applied %v", mainMenu.IsOpen(), applied)
+	}
+
+	press(BUTTON_MENU, true)
+	press(BUTTON_MENU, false)
+	press(BUTTON_PAGE, false)
+	press(BUTTON_MENU, false)
+
+	if fmt.Sprint(applied) != "[system]" {
+		t.Errorf("applied %v, want [system]", applied)
+	}
+
+	press(BUTTON_MENU, true)
+	press(BUTTON_DISMISS, false)
+	if _, ok := overlay.Current(); ok {
+		t.Error("pressing the dismiss button kept the notification")
+	}
+}
+
+func TestTimerButtons(t *testing.T) {
+	pager := pages.NewPager(&pages.Page{Name: "overview"}, &pages.Page{Name: "timer"})
+	overlay := notify.NewOverlay()
+	countdown := timer.NewCountdown(5*time.Minute, nil)
+
+	schedule, err := backlight.NewSchedule("", "UTC", backlight.LevelOn, 48, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatcher := newDispatcher(pager, overlay, schedule, menu.New(), countdown)
+	press := func(button int) {
+		dispatcher.Dispatch(input.ButtonEvent{Button: button, Action: input.ButtonDown})
+		dispatcher.Dispatch(input.ButtonEvent{Button: button, Action: input.ButtonUp})
+	}
+
+	// Away from the timer page the encoder and buttons do what they always do.
+	press(BUTTON_DISMISS)
+	if countdown.Running() {
+		t.Error("the dismiss button started the countdown on another page")
+	}
+
+	dispatcher.Dispatch(input.EncoderEvent{Delta: 1})
+	if page := pager.Active().Name; page != "timer" {
+		t.Fatalf("turning the encoder shows %s, want the timer", page)
+	}
+
+	dispatcher.Dispatch(input.EncoderEvent{Delta: 2})
+	if got := countdown.String(); got != "Timer 07:00 ready" || pager.Active().Name != "timer" {
+		t.Errorf("turning the encoder on the timer page shows %s %q", pager.Active().Name, got)
+	}
+
+	overlay.Post("door open", 0)
+	press(BUTTON_DISMISS)
+	if _, ok := overlay.Current(); ok || countdown.Running() {
+		t.Error("the dismiss button started the countdown over a notification")
+	}
+
+	press(BUTTON_DISMISS)
+	if !countdown.Running() {
+		t.Fatal("the dismiss button left the countdown standing")
+	}
+
+	// Running, the encoder moves pages again.
+	dispatcher.Dispatch(input.EncoderEvent{Delta: 1})
+	if page := pager.Active().Name; page != "overview" {
+		t.Errorf("turning the encoder with the countdown running shows %s", page)
+	}
+
+	countdown.Reset()
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fudanchii/szb/internal/mqtt"
+	"github.com/fudanchii/szb/internal/notify"
+	"github.com/fudanchii/szb/internal/pages"
+)
+
+const (
+	MQTT_PAGE       = "mqtt"
+	MQTT_NOTIFY_TTL = 10 * time.Second
+
+	MQTT_TO_LINE   = "line"
+	MQTT_TO_NOTIFY = "notify"
+)
+
+var (
+	ErrInvalidSubscription = errors.New("config: error parsing MQTT subscription, please specify topic=...;key=value... with keys topic, field, label, to (line or notify), page and ttl (e.g. topic=home/+/temp;field=temp;label=Temp ;page=home)")
+	ErrPageFull            = errors.New("config: error, more than 4 MQTT lines on one page")
+	ErrPageTaken           = errors.New("config: error, MQTT lines can not go on a built in page")
+)
+
+// subscriptionList collects every -mqtt-sub flag given.
+type subscriptionList []string
+
+func (sl *subscriptionList) String() string {
+	return strings.Join(*sl, " ")
+}
+
+func (sl *subscriptionList) Set(value string) error {
+	*sl = append(*sl, value)
+	return nil
+}
+
+// subscriptionSpec is one -mqtt-sub flag, what it shows goes either on a
+// page line or to the notification overlay.
+type subscriptionSpec struct {
+	topic string
+	field string
+	label string
+	to    string
+	page  string
+	ttl   time.Duration
+}
+
+func parseSubscriptionSpec(spec string) (subscriptionSpec, error) {
+	ss := subscriptionSpec{to: MQTT_TO_LINE, page: MQTT_PAGE, ttl: MQTT_NOTIFY_TTL}
+
+	for _, field := range strings.Split(spec, ";") {
+		// Labels keep their spaces, they are what sets them off from the
+		// value.
+		key, value, ok := strings.Cut(strings.TrimLeft(field, " "), "=")
+		if !ok {
+			return ss, fmt.Errorf("%w: %s", ErrInvalidSubscription, field)
+		}
+
+		switch key {
+		case "topic":
+			ss.topic = value
+		case "field":
+			ss.field = value
+		case "label":
+			ss.label = value
+		case "to":
+			ss.to = value
+		case "page":
+			ss.page = value
+		case "ttl":
+			ttl, err := time.ParseDuration(value)
+			if err != nil {
+				return ss, fmt.Errorf("%w: %s", ErrInvalidSubscription, field)
+			}
+
+			ss.ttl = ttl
+		default:
+			return ss, fmt.Errorf("%w: %s", ErrInvalidSubscription, field)
+		}
+	}
+
+	if ss.topic == "" || !slices.Contains([]string{MQTT_TO_LINE, MQTT_TO_NOTIFY}, ss.to) {
+		return ss, fmt.Errorf("%w: %s", ErrInvalidSubscription, spec)
+	}
+
+	return ss, nil
+}
+
+// newMQTTClient subscribes to every -mqtt-sub topic, line subscriptions
+// fill the lines of their page in order and the pages come after the
+// built in ones.
+func newMQTTClient(broker string, subs []string, overlay *notify.Overlay) (*mqtt.Client, []*pages.Page, error) {
+	client, err := mqtt.ParseURL(broker)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client.OnStateChange = func(connected bool, err error) {
+		switch {
+		case connected:
+			fmt.Fprintf(os.Stderr, "mqtt %s: connected\n", client.Addr)
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "mqtt %s: disconnected (%v)\n", client.Addr, err)
+		}
+	}
+
+	layout := []*pages.Page{}
+	used := map[string]int{}
+
+	for _, raw := range subs {
+		spec, err := parseSubscriptionSpec(raw)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if spec.to == MQTT_TO_NOTIFY {
+			client.Subscribe(spec.topic, func(msg mqtt.Message) {
+				// Retained messages are old news, they come again on
+				// every connect.
+				if msg.Retained {
+					return
+				}
+
+				text, err := mqtt.Extract(msg.Payload, spec.field)
+				if err != nil || text == "" {
+					return
+				}
+
+				overlay.Post(spec.label+text, spec.ttl)
+			})
+
+			continue
+		}
+
+		idx := slices.IndexFunc(layout, func(page *pages.Page) bool {
+			return page.Name == spec.page
+		})
+		if idx < 0 {
+			layout = append(layout, &pages.Page{Name: spec.page})
+			idx = len(layout) - 1
+		}
+
+		if used[spec.page] == len(layout[idx].Lines) {
+			return nil, nil, fmt.Errorf("%w: %s", ErrPageFull, spec.page)
+		}
+
+		value := mqtt.NewValue(spec.label, spec.field)
+		layout[idx].Lines[used[spec.page]] = value
+		used[spec.page]++
+
+		client.Subscribe(spec.topic, value.Update)
+	}
+
+	return client, layout, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fudanchii/szb/internal/transport"
+)
+
+// listPorts prints the serial ports szb could connect to, ports matching
+// a usb: transport given with -c are marked with a star.
+func listPorts() error {
+	ports, err := transport.ListPorts()
+	if err != nil {
+		return err
+	}
+
+	var usb *transport.USB
+	if conn, err := transport.Parse(config.connectTo, transport.DefaultSerialLine(config.baudRate)); err == nil {
+		usb, _ = conn.(*transport.USB)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "\tPORT\tVID\tPID\tSERIAL\tPRODUCT")
+
+	for _, port := range ports {
+		mark := ""
+		if usb != nil && usb.Match.Matches(port) {
+			mark = "*"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", mark, port.Name, port.VID, port.PID, port.SerialNumber, port.Product)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fudanchii/szb/internal/lcdimage"
+	"github.com/fudanchii/szb/internal/recording"
+)
+
+var (
+	ErrRenderUsage = errors.New("usage: szb render <recording> <out.gif | dir>")
+)
+
+// renderRecording draws a recording made with -record as an animated GIF
+// when out ends in .gif, with one image per frame shown as long as it was
+// on display. Otherwise every frame on display at the display rate goes
+// into a numbered PNG file in the directory out.
+func renderRecording(path, out string) error {
+	if path == "" || out == "" {
+		return ErrRenderUsage
+	}
+
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	reader, err := recording.NewReader(in)
+	if err != nil {
+		return err
+	}
+
+	renderer := lcdimage.NewRenderer(reader.Geometry())
+
+	if strings.EqualFold(filepath.Ext(out), ".gif") {
+		frames, delays, err := recording.Frames(reader, RENDER_HOLD_MS*time.Millisecond)
+		if err != nil {
+			return err
+		}
+
+		file, err := os.Create(out)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		return renderer.WriteGIF(file, frames, delays)
+	}
+
+	frames, err := recording.Sample(reader, DISPLAY_RATE_MS*time.Millisecond)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(out, 0o755); err != nil {
+		return err
+	}
+
+	return renderer.WritePNGSequence(out, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), frames)
+}
@@ -6,9 +6,7 @@ require (
 	github.com/briandowns/openweathermap v0.21.1
 	github.com/mackerelio/go-osstat v0.2.5
 	go.bug.st/serial v1.6.2
+	golang.org/x/sys v0.20.0
 )
 
-require (
-	github.com/creack/goselect v0.1.2 // indirect
-	golang.org/x/sys v0.20.0 // indirect
-)
+require github.com/creack/goselect v0.1.2 // indirect
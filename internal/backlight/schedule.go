@@ -0,0 +1,194 @@
+package backlight
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	LevelOff = 0
+	LevelOn  = 255
+)
+
+var (
+	ErrInvalidSchedule = errors.New("config: error parsing backlight schedule, please specify comma separated HH:MM=level (e.g. 23:00=dim,01:00=off,07:00=on)")
+)
+
+type Rule struct {
+	// At is the time of day the rule kicks in, counted from midnight.
+	At    time.Duration
+	Level int
+}
+
+type Schedule struct {
+	mu sync.Mutex
+
+	spec     string
+	rules    []Rule
+	timezone *time.Location
+	onLevel  int
+	dimLevel int
+
+	idleAfter    time.Duration
+	lastActivity time.Time
+}
+
+func NewSchedule(spec, timezone string, onLevel, dimLevel int, idleAfter time.Duration) (*Schedule, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	sched := &Schedule{
+		spec:         spec,
+		timezone:     loc,
+		onLevel:      onLevel,
+		dimLevel:     dimLevel,
+		idleAfter:    idleAfter,
+		lastActivity: time.Now(),
+	}
+
+	sched.rules, err = sched.parseRules(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	return sched, nil
+}
+
+func (sched *Schedule) parseRules(spec string) ([]Rule, error) {
+	rules := []Rule{}
+
+	if strings.TrimSpace(spec) == "" {
+		return rules, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		at, level, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, ErrInvalidSchedule
+		}
+
+		clock, err := time.Parse("15:04", at)
+		if err != nil {
+			return nil, ErrInvalidSchedule
+		}
+
+		lvl, err := sched.parseLevel(level)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, Rule{
+			At:    time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute,
+			Level: lvl,
+		})
+	}
+
+	slices.SortFunc(rules, func(a, b Rule) int {
+		return int(a.At - b.At)
+	})
+
+	return rules, nil
+}
+
+func (sched *Schedule) parseLevel(level string) (int, error) {
+	switch level {
+	case "on":
+		return sched.onLevel, nil
+	case "dim":
+		return sched.dimLevel, nil
+	case "off":
+		return LevelOff, nil
+	}
+
+	lvl, err := strconv.Atoi(level)
+	if err != nil || lvl < LevelOff || lvl > LevelOn {
+		return 0, fmt.Errorf("config: error invalid backlight level %q", level)
+	}
+
+	return lvl, nil
+}
+
+func (sched *Schedule) SetTimezone(timezone string) error {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return err
+	}
+
+	sched.mu.Lock()
+	sched.timezone = loc
+	sched.mu.Unlock()
+
+	return nil
+}
+
+// SetOnLevel changes the level used when on, rules naming `on` follow.
+func (sched *Schedule) SetOnLevel(level int) error {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	prevLevel := sched.onLevel
+	sched.onLevel = level
+
+	rules, err := sched.parseRules(sched.spec)
+	if err != nil {
+		sched.onLevel = prevLevel
+		return err
+	}
+
+	sched.rules = rules
+
+	return nil
+}
+
+func (sched *Schedule) OnLevel() int {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	return sched.onLevel
+}
+
+// Touch marks user activity, which lifts the idle dim.
+func (sched *Schedule) Touch(now time.Time) {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	sched.lastActivity = now
+}
+
+// Level returns the backlight level to use at now. The last rule of the day
+// carries over past midnight until the first rule of the next day.
+func (sched *Schedule) Level(now time.Time) int {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+
+	level := sched.onLevel
+
+	if len(sched.rules) > 0 {
+		local := now.In(sched.timezone)
+		sinceMidnight := time.Duration(local.Hour())*time.Hour +
+			time.Duration(local.Minute())*time.Minute +
+			time.Duration(local.Second())*time.Second
+
+		level = sched.rules[len(sched.rules)-1].Level
+		for _, rule := range sched.rules {
+			if rule.At > sinceMidnight {
+				break
+			}
+
+			level = rule.Level
+		}
+	}
+
+	if sched.idleAfter > 0 && now.Sub(sched.lastActivity) >= sched.idleAfter {
+		level = min(level, sched.dimLevel)
+	}
+
+	return level
+}
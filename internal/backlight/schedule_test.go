@@ -0,0 +1,60 @@
+package backlight
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleLevel(t *testing.T) {
+	sched, err := NewSchedule("23:00=dim,01:00=off,07:00=on", "UTC", 200, 40, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		clock string
+		want  int
+	}{
+		{"00:30", 40},
+		{"01:00", LevelOff},
+		{"06:59", LevelOff},
+		{"07:00", 200},
+		{"22:59", 200},
+		{"23:30", 40},
+	}
+
+	for _, c := range cases {
+		clock, _ := time.Parse("15:04", c.clock)
+		now := time.Date(2024, 1, 2, clock.Hour(), clock.Minute(), 0, 0, time.UTC)
+
+		if got := sched.Level(now); got != c.want {
+			t.Errorf("Level at %s = %d, want %d", c.clock, got, c.want)
+		}
+	}
+}
+
+func TestScheduleIdleDim(t *testing.T) {
+	sched, err := NewSchedule("", "UTC", 200, 40, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	sched.Touch(now)
+
+	if got := sched.Level(now.Add(30 * time.Second)); got != 200 {
+		t.Errorf("Level before idle = %d, want 200", got)
+	}
+
+	if got := sched.Level(now.Add(2 * time.Minute)); got != 40 {
+		t.Errorf("Level after idle = %d, want 40", got)
+	}
+}
+
+func TestScheduleInvalid(t *testing.T) {
+	for _, spec := range []string{"23:00", "25:00=on", "07:00=bright", "07:00=300"} {
+		if _, err := NewSchedule(spec, "UTC", 200, 40, 0); err == nil {
+			t.Errorf("NewSchedule(%q) should fail", spec)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Package control lets a running szb be driven from outside, over HTTP
+// or a unix socket.
+package control
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/fudanchii/szb/internal/input"
+)
+
+var (
+	ErrUnknownDisplay = errors.New("control: error, no display with that name")
+)
+
+// Controller is what can be changed and looked at in a running szb.
+// Displays are picked by name, the one set up by the top level flags is
+// called "" and an empty name picks every display where that makes sense.
+type Controller interface {
+	Notify(text string, ttl time.Duration)
+	// SetLine pins text on line idx, 0 to 3, of page, UnsetLine gives the
+	// line back to its source.
+	SetLine(page string, idx int, text string) error
+	UnsetLine(page string, idx int) error
+	SwitchPage(display, page string) error
+	SetBacklight(display string, level int) error
+	// Snapshot is what the display shows, the first display when the
+	// name is empty.
+	Snapshot(display string) (Snapshot, error)
+	Values() []PageValues
+	Status() Status
+	// Input hands event to the display as if it came from the device.
+	Input(display string, event input.Event) error
+	// FrameChanged is closed once the display shows something new, it
+	// picks the display like Snapshot.
+	FrameChanged(display string) (<-chan struct{}, error)
+}
+
+// Snapshot is what a display shows.
+type Snapshot struct {
+	Display string   `json:"display"`
+	Page    string   `json:"page"`
+	Cols    int      `json:"cols"`
+	Rows    int      `json:"rows"`
+	Lines   []string `json:"lines"`
+
+	// WritePNG draws the display as it looks.
+	WritePNG func(w io.Writer) error `json:"-"`
+}
+
+// PageValues are the current texts of the line sources on a page.
+type PageValues struct {
+	Page  string   `json:"page"`
+	Lines []string `json:"lines"`
+}
+
+// Status is how szb is doing.
+type Status struct {
+	Displays      []DisplayStatus `json:"displays"`
+	Notifications []string        `json:"notifications"`
+}
+
+type DisplayStatus struct {
+	Name      string   `json:"name"`
+	Target    string   `json:"target"`
+	State     string   `json:"state"`
+	Page      string   `json:"page"`
+	Pages     []string `json:"pages"`
+	Backlight int      `json:"backlight"`
+}
@@ -0,0 +1,320 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fudanchii/szb/internal/backlight"
+	"github.com/fudanchii/szb/internal/pages"
+)
+
+const (
+	maxBody = 64 * 1024
+
+	FormatText = "text"
+	FormatJSON = "json"
+	FormatPNG  = "png"
+)
+
+var (
+	ErrInvalidTTL    = errors.New("control: error parsing ttl, please specify a duration (e.g. 30s)")
+	ErrInvalidFormat = errors.New("control: error, unknown format, use text, json or png")
+	ErrInvalidLine   = errors.New("control: error parsing line, please specify a line number from 1")
+	ErrInvalidLevel  = errors.New("control: error, backlight level out of range, please specify 0 to 255")
+	ErrNotJSON       = errors.New("control: error, please send the request body as application/json")
+	ErrForeignHost   = errors.New("control: error, unknown host name, use the address szb listens on")
+)
+
+// Server serves the control API over HTTP:
+//
+//	POST   /api/notify                    {"text": "...", "ttl": "30s"}
+//	PUT    /api/pages/{page}/lines/{line} {"text": "..."}
+//	DELETE /api/pages/{page}/lines/{line}
+//	POST   /api/page                      {"display": "", "page": "system"}
+//	POST   /api/backlight                 {"display": "", "level": 128}
+//	GET    /api/frame?display=&format=text|json|png
+//	GET    /api/values
+//	GET    /api/status
+//	POST   /api/input                     {"display": "", "event": "btn:1:down"}
+//	GET    /api/events?display=           frames as server-sent events
+//	GET    /                              a live preview with buttons
+//
+// Request bodies must be sent as application/json. Over TCP the Host of a
+// request has to be the listen address, localhost or an IP address.
+type Server struct {
+	ln   net.Listener
+	http *http.Server
+
+	// stop ends the requests that would go on forever, like event
+	// streams, when shutting down.
+	stop context.CancelFunc
+}
+
+func Listen(addr string, ctl Controller) (*Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(ln, checkHost(addr, NewHandler(ctl))), nil
+}
+
+func newServer(ln net.Listener, handler http.Handler) *Server {
+	ctx, stop := context.WithCancel(context.Background())
+
+	return &Server{
+		ln: ln,
+		http: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: 5 * time.Second,
+			BaseContext: func(net.Listener) context.Context {
+				return ctx
+			},
+		},
+		stop: stop,
+	}
+}
+
+// checkHost only lets through requests for the host szb listens on, for
+// localhost or for an IP address. A page whose name is rebound to szb's
+// address would pass for the same origin otherwise.
+func checkHost(addr string, next http.Handler) http.Handler {
+	listenHost, _, _ := net.SplitHostPort(addr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = strings.Trim(r.Host, "[]")
+		}
+
+		if net.ParseIP(host) == nil && !strings.EqualFold(host, "localhost") && !strings.EqualFold(host, listenHost) {
+			fail(w, fmt.Errorf("%w: %s", ErrForeignHost, host))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.ln.Addr()
+}
+
+// Serve handles requests until Shutdown.
+func (s *Server) Serve() error {
+	if err := s.http.Serve(s.ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Shutdown stops taking requests and waits for the ones going on until
+// ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stop()
+
+	return s.http.Shutdown(ctx)
+}
+
+type notifyRequest struct {
+	Text string `json:"text"`
+	TTL  string `json:"ttl"`
+}
+
+type lineRequest struct {
+	Text string `json:"text"`
+}
+
+type pageRequest struct {
+	Display string `json:"display"`
+	Page    string `json:"page"`
+}
+
+type backlightRequest struct {
+	Display string `json:"display"`
+	Level   int    `json:"level"`
+}
+
+func NewHandler(ctl Controller) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST /api/notify", func(w http.ResponseWriter, r *http.Request) {
+		var req notifyRequest
+		if !decode(w, r, &req) {
+			return
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl < 0 {
+				fail(w, fmt.Errorf("%w: %s", ErrInvalidTTL, req.TTL))
+				return
+			}
+		}
+
+		ctl.Notify(req.Text, ttl)
+		ok(w)
+	})
+
+	mux.HandleFunc("PUT /api/pages/{page}/lines/{line}", func(w http.ResponseWriter, r *http.Request) {
+		idx, err := lineIndex(r)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+
+		var req lineRequest
+		if !decode(w, r, &req) {
+			return
+		}
+
+		reply(w, ctl.SetLine(r.PathValue("page"), idx, req.Text))
+	})
+
+	mux.HandleFunc("DELETE /api/pages/{page}/lines/{line}", func(w http.ResponseWriter, r *http.Request) {
+		idx, err := lineIndex(r)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+
+		reply(w, ctl.UnsetLine(r.PathValue("page"), idx))
+	})
+
+	mux.HandleFunc("POST /api/page", func(w http.ResponseWriter, r *http.Request) {
+		var req pageRequest
+		if !decode(w, r, &req) {
+			return
+		}
+
+		reply(w, ctl.SwitchPage(req.Display, req.Page))
+	})
+
+	mux.HandleFunc("POST /api/backlight", func(w http.ResponseWriter, r *http.Request) {
+		var req backlightRequest
+		if !decode(w, r, &req) {
+			return
+		}
+
+		if req.Level < backlight.LevelOff || req.Level > backlight.LevelOn {
+			fail(w, fmt.Errorf("%w: %d", ErrInvalidLevel, req.Level))
+			return
+		}
+
+		reply(w, ctl.SetBacklight(req.Display, req.Level))
+	})
+
+	mux.HandleFunc("GET /api/frame", func(w http.ResponseWriter, r *http.Request) {
+		snapshot, err := ctl.Snapshot(r.URL.Query().Get("display"))
+		if err != nil {
+			fail(w, err)
+			return
+		}
+
+		switch format := r.URL.Query().Get("format"); format {
+		case "", FormatText:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintln(w, strings.Join(snapshot.Lines, "\n"))
+		case FormatJSON:
+			writeJSON(w, http.StatusOK, snapshot)
+		case FormatPNG:
+			var image bytes.Buffer
+			if err := snapshot.WritePNG(&image); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(image.Bytes())
+		default:
+			fail(w, fmt.Errorf("%w: %s", ErrInvalidFormat, format))
+		}
+	})
+
+	mux.HandleFunc("GET /api/values", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, ctl.Values())
+	})
+
+	mux.HandleFunc("GET /api/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, ctl.Status())
+	})
+
+	handlePreview(mux, ctl)
+
+	return mux
+}
+
+// lineIndex turns the line in the path, counted from 1, into an index.
+// lineIndex reads the line number off the path, the page it goes to says
+// how many lines there are.
+func lineIndex(r *http.Request) (int, error) {
+	line, err := strconv.Atoi(r.PathValue("line"))
+	if err != nil || line < 1 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidLine, r.PathValue("line"))
+	}
+
+	return line - 1, nil
+}
+
+// decode only reads JSON bodies, a form or text/plain body can be posted by
+// any page in a browser without asking first.
+func decode(w http.ResponseWriter, r *http.Request, req any) bool {
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		fail(w, ErrNotJSON)
+		return false
+	}
+
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(req); err != nil {
+		fail(w, err)
+		return false
+	}
+
+	return true
+}
+
+func reply(w http.ResponseWriter, err error) {
+	if err != nil {
+		fail(w, err)
+		return
+	}
+
+	ok(w)
+}
+
+func ok(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]bool{"ok": true})
+}
+
+func fail(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, ErrUnknownDisplay) || errors.Is(err, pages.ErrUnknownPage) {
+		status = http.StatusNotFound
+	}
+
+	if errors.Is(err, ErrNotJSON) {
+		status = http.StatusUnsupportedMediaType
+	}
+
+	if errors.Is(err, ErrForeignHost) {
+		status = http.StatusForbidden
+	}
+
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
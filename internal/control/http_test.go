@@ -0,0 +1,319 @@
+package control
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/pages"
+)
+
+type fakeController struct {
+	calls   []string
+	changed chan struct{}
+	pngErr  error
+}
+
+func (fc *fakeController) Notify(text string, ttl time.Duration) {
+	fc.calls = append(fc.calls, fmt.Sprintf("notify %q %s", text, ttl))
+}
+
+func (fc *fakeController) SetLine(page string, idx int, text string) error {
+	if err := (&pages.Page{Name: page}).Pin(idx, text); err != nil {
+		return err
+	}
+
+	fc.calls = append(fc.calls, fmt.Sprintf("set %s %d %q", page, idx, text))
+
+	return nil
+}
+
+func (fc *fakeController) UnsetLine(page string, idx int) error {
+	if page != "system" {
+		return fmt.Errorf("%w: %s", pages.ErrUnknownPage, page)
+	}
+
+	fc.calls = append(fc.calls, fmt.Sprintf("unset %s %d", page, idx))
+
+	return nil
+}
+
+func (fc *fakeController) SwitchPage(display, page string) error {
+	fc.calls = append(fc.calls, fmt.Sprintf("page %q %s", display, page))
+	return nil
+}
+
+func (fc *fakeController) SetBacklight(display string, level int) error {
+	fc.calls = append(fc.calls, fmt.Sprintf("backlight %q %d", display, level))
+	return nil
+}
+
+func (fc *fakeController) Snapshot(display string) (Snapshot, error) {
+	if display != "" {
+		return Snapshot{}, fmt.Errorf("%w: %s", ErrUnknownDisplay, display)
+	}
+
+	return Snapshot{
+		Page:  "overview",
+		Cols:  16,
+		Rows:  2,
+		Lines: []string{"hello", "world"},
+		WritePNG: func(w io.Writer) error {
+			if fc.pngErr != nil {
+				return fc.pngErr
+			}
+
+			_, err := w.Write([]byte("\x89PNG"))
+			return err
+		},
+	}, nil
+}
+
+func (fc *fakeController) Values() []PageValues {
+	return []PageValues{{Page: "overview", Lines: []string{"a", "b", "", ""}}}
+}
+
+func (fc *fakeController) Status() Status {
+	return Status{
+		Displays:      []DisplayStatus{{Name: "", Target: "sim:", State: "connected", Page: "overview"}},
+		Notifications: []string{"door open"},
+	}
+}
+
+func (fc *fakeController) Input(display string, event input.Event) error {
+	fc.calls = append(fc.calls, fmt.Sprintf("input %q %+v", display, event))
+	return nil
+}
+
+func (fc *fakeController) FrameChanged(display string) (<-chan struct{}, error) {
+	if display != "" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDisplay, display)
+	}
+
+	return fc.changed, nil
+}
+
+func TestHandler(t *testing.T) {
+	ctl := &fakeController{}
+	server := httptest.NewServer(NewHandler(ctl))
+	defer server.Close()
+
+	send := func(method, path, contentType, body string) (int, string) {
+		t.Helper()
+
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+
+		out, _ := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, string(out)
+	}
+
+	do := func(method, path, body string) (int, string) {
+		t.Helper()
+
+		if body == "" {
+			return send(method, path, "", body)
+		}
+
+		return send(method, path, "application/json; charset=utf-8", body)
+	}
+
+	cases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"POST", "/api/notify", `{"text": "door open", "ttl": "30s"}`, http.StatusOK},
+		{"POST", "/api/notify", `{"text": "x", "ttl": "soon"}`, http.StatusBadRequest},
+		{"PUT", "/api/pages/overview/lines/2", `{"text": "pinned"}`, http.StatusOK},
+		{"PUT", "/api/pages/overview/lines/5", `{"text": "x"}`, http.StatusBadRequest},
+		{"PUT", "/api/pages/overview/lines/0", `{"text": "x"}`, http.StatusBadRequest},
+		{"PUT", "/api/pages/overview/lines/two", `{"text": "x"}`, http.StatusBadRequest},
+		{"DELETE", "/api/pages/system/lines/1", ``, http.StatusOK},
+		{"DELETE", "/api/pages/weather/lines/1", ``, http.StatusNotFound},
+		{"POST", "/api/page", `{"display": "desk", "page": "system"}`, http.StatusOK},
+		{"POST", "/api/backlight", `{"level": 64}`, http.StatusOK},
+		{"POST", "/api/backlight", `{"level": 999}`, http.StatusBadRequest},
+		{"POST", "/api/backlight", `not json`, http.StatusBadRequest},
+		{"GET", "/api/frame?display=desk", ``, http.StatusNotFound},
+		{"GET", "/api/frame?format=gif", ``, http.StatusBadRequest},
+		{"GET", "/api/notify", ``, http.StatusMethodNotAllowed},
+		{"POST", "/api/input", `{"event": "btn:1:long"}`, http.StatusOK},
+		{"POST", "/api/input", `{"event": "press"}`, http.StatusBadRequest},
+	}
+
+	for _, c := range cases {
+		if status, body := do(c.method, c.path, c.body); status != c.status {
+			t.Errorf("%s %s = %d %s, want %d", c.method, c.path, status, body, c.status)
+		}
+	}
+
+	// Bodies a browser sends from any page without a preflight.
+	for _, contentType := range []string{"", "text/plain", "application/x-www-form-urlencoded"} {
+		if status, body := send("POST", "/api/notify", contentType, `{"text": "from another site"}`); status != http.StatusUnsupportedMediaType {
+			t.Errorf("notify as %q = %d %s, want %d", contentType, status, body, http.StatusUnsupportedMediaType)
+		}
+	}
+
+	want := []string{
+		`notify "door open" 30s`,
+		`set overview 1 "pinned"`,
+		`unset system 0`,
+		`page "desk" system`,
+		`backlight "" 64`,
+		`input "" {Button:1 Action:long}`,
+	}
+	if got := strings.Join(ctl.calls, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("calls:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+
+	if _, body := do("GET", "/api/frame", ""); body != "hello\nworld\n" {
+		t.Errorf("text frame = %q", body)
+	}
+
+	var snapshot Snapshot
+	_, body := do("GET", "/api/frame?format=json", "")
+	if err := json.Unmarshal([]byte(body), &snapshot); err != nil || snapshot.Cols != 16 || snapshot.Lines[1] != "world" {
+		t.Errorf("json frame = %s (%v)", body, err)
+	}
+
+	if _, body := do("GET", "/api/frame?format=png", ""); !bytes.HasPrefix([]byte(body), []byte("\x89PNG")) {
+		t.Errorf("png frame = %q", body)
+	}
+
+	ctl.pngErr = errors.New("lcdimage: error, out of ink")
+	if status, body := do("GET", "/api/frame?format=png", ""); status != http.StatusInternalServerError {
+		t.Errorf("png frame that failed to draw = %d %q", status, body)
+	}
+	ctl.pngErr = nil
+
+	var values []PageValues
+	_, body = do("GET", "/api/values", "")
+	if err := json.Unmarshal([]byte(body), &values); err != nil || len(values) != 1 || values[0].Lines[1] != "b" {
+		t.Errorf("values = %s (%v)", body, err)
+	}
+
+	if status, body := do("GET", "/", ""); status != http.StatusOK || !strings.Contains(body, "api/events") {
+		t.Errorf("preview = %d %q", status, body)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	ctl := &fakeController{changed: make(chan struct{})}
+	server := httptest.NewServer(NewHandler(ctl))
+	defer server.Close()
+
+	if resp, err := http.Get(server.URL + "/api/events?display=desk"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("unknown display = %v (%v)", resp.Status, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("content type = %q", ct)
+	}
+
+	frames := bufio.NewScanner(resp.Body)
+	next := func() frameEvent {
+		t.Helper()
+
+		for frames.Scan() {
+			data, ok := strings.CutPrefix(frames.Text(), "data: ")
+			if !ok {
+				continue
+			}
+
+			var frame frameEvent
+			if err := json.Unmarshal([]byte(data), &frame); err != nil {
+				t.Fatal(err)
+			}
+
+			return frame
+		}
+
+		t.Fatalf("stream ended: %v", frames.Err())
+		return frameEvent{}
+	}
+
+	if frame := next(); frame.Page != "overview" || !strings.HasPrefix(frame.Image, "data:image/png;base64,iVBORw") {
+		t.Errorf("first frame = %+v", frame)
+	}
+
+	close(ctl.changed)
+
+	if frame := next(); frame.Lines[0] != "hello" {
+		t.Errorf("next frame = %+v", frame)
+	}
+}
+
+func TestHostCheck(t *testing.T) {
+	server := httptest.NewServer(checkHost("szb.lan:8080", NewHandler(&fakeController{})))
+	defer server.Close()
+
+	cases := []struct {
+		host   string
+		status int
+	}{
+		{"szb.lan:8080", http.StatusOK},
+		{"SZB.lan", http.StatusOK},
+		{"localhost:8080", http.StatusOK},
+		{"127.0.0.1:8080", http.StatusOK},
+		{"[::1]:8080", http.StatusOK},
+		{"192.168.1.20", http.StatusOK},
+		// A name rebound to szb's address.
+		{"attacker.example:8080", http.StatusForbidden},
+		{"szb.lan.attacker.example", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/api/values", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Host = c.host
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != c.status {
+			t.Errorf("Host %s = %d, want %d", c.host, resp.StatusCode, c.status)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package control
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// peerAllowed asks the kernel who is on the other end of conn.
+func peerAllowed(conn net.Conn) bool {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return false
+	}
+
+	raw, err := unixConn.SyscallConn()
+	if err != nil {
+		return false
+	}
+
+	var cred *unix.Ucred
+	var credErr error
+
+	err = raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	})
+	if err != nil || credErr != nil {
+		return false
+	}
+
+	return uidAllowed(cred.Uid)
+}
+
+func ownerAllowed(info os.FileInfo) bool {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+
+	return ok && uidAllowed(stat.Uid)
+}
+
+func uidAllowed(uid uint32) bool {
+	return uid == 0 || uid == uint32(os.Getuid())
+}
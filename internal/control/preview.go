@@ -0,0 +1,128 @@
+package control
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/fudanchii/szb/internal/input"
+)
+
+const (
+	// keepAlive is how often an idle event stream gets a comment, so
+	// proxies do not take it for dead.
+	keepAlive = 15 * time.Second
+)
+
+var (
+	ErrInvalidInput = errors.New("control: error parsing input event, please specify a device token (e.g. btn:1:down or enc:+1)")
+	ErrNoStreaming  = errors.New("control: error, connection can not stream events")
+)
+
+//go:embed preview.html
+var previewPage []byte
+
+type inputRequest struct {
+	Display string `json:"display"`
+	Event   string `json:"event"`
+}
+
+// frameEvent is a Snapshot with the image in it, as a data URL.
+type frameEvent struct {
+	Snapshot
+	Image string `json:"image"`
+}
+
+func handlePreview(mux *http.ServeMux, ctl Controller) {
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(previewPage)
+	})
+
+	mux.HandleFunc("POST /api/input", func(w http.ResponseWriter, r *http.Request) {
+		var req inputRequest
+		if !decode(w, r, &req) {
+			return
+		}
+
+		event, ok := input.Parse(req.Event)
+		if !ok {
+			fail(w, fmt.Errorf("%w: %s", ErrInvalidInput, req.Event))
+			return
+		}
+
+		reply(w, ctl.Input(req.Display, event))
+	})
+
+	mux.HandleFunc("GET /api/events", func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			fail(w, ErrNoStreaming)
+			return
+		}
+
+		display := r.URL.Query().Get("display")
+
+		// The first frame goes out right away, the rest as they come.
+		changed, err := ctl.FrameChanged(display)
+		if err != nil {
+			fail(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		ticker := time.NewTicker(keepAlive)
+		defer ticker.Stop()
+
+		for {
+			snapshot, err := ctl.Snapshot(display)
+			if err != nil {
+				return
+			}
+
+			data, err := frameData(snapshot)
+			if err != nil {
+				return
+			}
+
+			fmt.Fprintf(w, "event: frame\ndata: %s\n\n", data)
+			flusher.Flush()
+
+		wait:
+			for {
+				select {
+				case <-r.Context().Done():
+					return
+				case <-ticker.C:
+					fmt.Fprint(w, ": keep alive\n\n")
+					flusher.Flush()
+				case <-changed:
+					break wait
+				}
+			}
+
+			if changed, err = ctl.FrameChanged(display); err != nil {
+				return
+			}
+		}
+	})
+}
+
+func frameData(snapshot Snapshot) ([]byte, error) {
+	var image bytes.Buffer
+	if err := snapshot.WritePNG(&image); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(frameEvent{
+		Snapshot: snapshot,
+		Image:    "data:image/png;base64," + base64.StdEncoding.EncodeToString(image.Bytes()),
+	})
+}
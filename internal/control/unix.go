@@ -0,0 +1,235 @@
+package control
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// SocketMode keeps the socket to the user running szb, peers are
+	// checked again on accept where the system tells who they are.
+	SocketMode = 0o600
+
+	clientTimeout = 5 * time.Second
+)
+
+var (
+	ErrSocketInUse = errors.New("control: error, another szb is listening on the socket")
+	ErrNotSocket   = errors.New("control: error, path is not a socket")
+	ErrSocketOwner = errors.New("control: error, socket belongs to another user")
+	ErrBadReply    = errors.New("control: error, unexpected answer from szb")
+)
+
+// DefaultSocketPath is in the runtime directory of the user when there is
+// one.
+func DefaultSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "szb.sock")
+	}
+
+	return filepath.Join(os.TempDir(), fmt.Sprintf("szb-%d.sock", os.Getuid()))
+}
+
+// ListenUnix serves the control API on a socket at path, taking over a
+// socket left behind by an szb that is gone.
+func ListenUnix(path string, ctl Controller) (*Server, error) {
+	if err := removeStale(path); err != nil {
+		return nil, err
+	}
+
+	ln, err := listenPrivate(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return newServer(&peerListener{Listener: ln, path: path}, NewHandler(ctl)), nil
+}
+
+// listenPrivate binds the socket in a directory only this user can enter
+// and moves it to path once it has SocketMode, so nobody else can connect
+// while it still has the mode the umask gave it.
+func listenPrivate(path string) (*net.UnixListener, error) {
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".szb-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	bound := filepath.Join(dir, "sock")
+
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: bound, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+
+	// The socket is unlinked at path by peerListener instead.
+	ln.SetUnlinkOnClose(false)
+
+	if err := os.Chmod(bound, SocketMode); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	if err := os.Rename(bound, path); err != nil {
+		ln.Close()
+		return nil, err
+	}
+
+	return ln, nil
+}
+
+func removeStale(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Type() != os.ModeSocket {
+		return fmt.Errorf("%w: %s", ErrNotSocket, path)
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("%w: %s", ErrSocketInUse, path)
+	}
+
+	return os.Remove(path)
+}
+
+// peerListener hangs up on peers run by other users, root aside, and
+// removes the socket at path once closed.
+type peerListener struct {
+	net.Listener
+	path string
+}
+
+func (pl *peerListener) Close() error {
+	err := pl.Listener.Close()
+	os.Remove(pl.path)
+
+	return err
+}
+
+func (pl *peerListener) Accept() (net.Conn, error) {
+	for {
+		conn, err := pl.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		if peerAllowed(conn) {
+			return conn, nil
+		}
+
+		conn.Close()
+	}
+}
+
+// Client talks to a running szb over its socket.
+type Client struct {
+	http *http.Client
+}
+
+// DialUnix checks that the socket at path belongs to this user, or root,
+// before anything is sent to it.
+func DialUnix(path string) (*Client, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.Mode().Type() != os.ModeSocket {
+		return nil, fmt.Errorf("%w: %s", ErrNotSocket, path)
+	}
+
+	if !ownerAllowed(info) {
+		return nil, fmt.Errorf("%w: %s", ErrSocketOwner, path)
+	}
+
+	return &Client{
+		http: &http.Client{
+			Timeout: clientTimeout,
+			Transport: &http.Transport{
+				DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+					return net.DialTimeout("unix", path, clientTimeout)
+				},
+			},
+		},
+	}, nil
+}
+
+func (c *Client) Notify(text string, ttl time.Duration) error {
+	return c.do(http.MethodPost, "/api/notify", notifyRequest{Text: text, TTL: ttl.String()}, nil)
+}
+
+func (c *Client) SwitchPage(display, page string) error {
+	return c.do(http.MethodPost, "/api/page", pageRequest{Display: display, Page: page}, nil)
+}
+
+func (c *Client) Status() (Status, error) {
+	var status Status
+	err := c.do(http.MethodGet, "/api/status", nil, &status)
+
+	return status, err
+}
+
+// do sends req as JSON and reads the answer into reply, errors from szb
+// come back as they were written there.
+func (c *Client) do(method, path string, req, reply any) error {
+	var body io.Reader = http.NoBody
+	if req != nil {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return err
+		}
+
+		body = bytes.NewReader(data)
+	}
+
+	request, err := http.NewRequest(method, (&url.URL{Scheme: "http", Host: "szb", Path: path}).String(), body)
+	if err != nil {
+		return err
+	}
+
+	if req != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Error string `json:"error"`
+		}
+
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == "" {
+			return fmt.Errorf("%w: %s", ErrBadReply, resp.Status)
+		}
+
+		return errors.New(failure.Error)
+	}
+
+	if reply == nil {
+		return nil
+	}
+
+	return json.NewDecoder(resp.Body).Decode(reply)
+}
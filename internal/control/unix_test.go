@@ -0,0 +1,88 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "szb.sock")
+
+	// A socket left behind by an szb that is gone is taken over.
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ctl := &fakeController{}
+	server, err := ListenUnix(path, ctl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	go server.Serve()
+
+	info, err := os.Stat(path)
+	if err != nil || info.Mode().Perm() != SocketMode {
+		t.Errorf("socket mode = %v (%v)", info.Mode(), err)
+	}
+
+	if _, err := ListenUnix(path, ctl); !errors.Is(err, ErrSocketInUse) {
+		t.Errorf("second ListenUnix err = %v, want ErrSocketInUse", err)
+	}
+
+	client, err := DialUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Notify("deploy done", 30*time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.SwitchPage("desk", "weather"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ctl.calls) != 2 || ctl.calls[0] != `notify "deploy done" 30s` || ctl.calls[1] != `page "desk" weather` {
+		t.Errorf("calls = %q", ctl.calls)
+	}
+
+	status, err := client.Status()
+	if err != nil || len(status.Displays) != 1 || status.Displays[0].State != "connected" {
+		t.Errorf("Status = %+v, %v", status, err)
+	}
+
+	// Nothing is left behind, the directory the socket was bound in
+	// included.
+	if err := server.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries, err := os.ReadDir(filepath.Dir(path)); err != nil || len(entries) != 0 {
+		t.Errorf("left behind %v (%v)", entries, err)
+	}
+}
+
+func TestUnixSocketNotSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "szb.sock")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ListenUnix(path, &fakeController{}); !errors.Is(err, ErrNotSocket) {
+		t.Errorf("ListenUnix err = %v, want ErrNotSocket", err)
+	}
+
+	if _, err := DialUnix(path); !errors.Is(err, ErrNotSocket) {
+		t.Errorf("DialUnix err = %v, want ErrNotSocket", err)
+	}
+}
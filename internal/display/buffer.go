@@ -1,11 +1,13 @@
 package display
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 var (
@@ -16,8 +18,9 @@ var (
 type OverflowStyle interface {
 	ImplOverflowStyle()
 
-	NextRender(*CharLcdBuffer) []byte
+	NextRender([]byte) []byte
 
+	setGeometry(Geometry)
 	setLine1(string)
 	setLine2(string) error
 	setLine3(string) error
@@ -71,6 +74,7 @@ type NoWrapOverflowStyle interface {
 	ImplNoWrapOverflowStyle()
 
 	NextRender([]byte)
+	setWidth(int)
 	setCurrentLine(string)
 }
 
@@ -90,10 +94,20 @@ func (BaseOverflowStyle) setLine4(line string) error {
 	return ErrSettingThisLine
 }
 
-type BaseNoWrapOverflowStyle struct{}
+type BaseNoWrapOverflowStyle struct {
+	width int
+}
 
 func (BaseNoWrapOverflowStyle) ImplNoWrapOverflowStyle() {}
 
+func (bnw *BaseNoWrapOverflowStyle) cols() int {
+	if bnw.width == 0 {
+		return DefaultGeometry.Cols
+	}
+
+	return bnw.width
+}
+
 type OfEndlessMarquee struct {
 	BaseNoWrapOverflowStyle
 
@@ -113,10 +127,10 @@ func (oem *OfEndlessMarquee) NextRender(currentBuffer []byte) {
 
 	oem.counter = 0
 	trailer := []byte{}
-	endPos := oem.pos + 20
+	endPos := oem.pos + oem.cols()
 	if endPos >= len(oem.line) {
 		endPos = len(oem.line)
-		trailer = oem.nextLine[:20-(endPos-oem.pos)]
+		trailer = oem.nextLine[:oem.cols()-(endPos-oem.pos)]
 	}
 
 	copy(currentBuffer[:], slices.Concat([]byte(oem.line[oem.pos:endPos]), []byte(trailer)))
@@ -130,12 +144,20 @@ func (oem *OfEndlessMarquee) NextRender(currentBuffer []byte) {
 	oem.rendered = true
 }
 
+func (oem *OfEndlessMarquee) setWidth(width int) {
+	oem.width = width
+	oem.line = nil
+	oem.nextLine = nil
+	oem.pos = 0
+	oem.rendered = false
+}
+
 func (oem *OfEndlessMarquee) setCurrentLine(line string) {
-	if len(line) >= 20 {
+	if len(line) >= oem.cols() {
 		line += " . "
 	}
 
-	oem.nextLine = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-20s", line))
+	oem.nextLine = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-*s", oem.cols(), line))
 	if len(oem.line) == 0 {
 		oem.line = oem.nextLine
 		oem.pos = 0
@@ -162,9 +184,9 @@ func (ocm *OfCycleMarquee) NextRender(currentBuffer []byte) {
 
 	ocm.counter = 0
 
-	if len(ocm.nextLine) == 20 {
+	if len(ocm.nextLine) == ocm.cols() {
 		if ocm.changed {
-			copy(currentBuffer[:], ocm.nextLine[:20])
+			copy(currentBuffer[:], ocm.nextLine[:ocm.cols()])
 
 			ocm.line = ocm.nextLine
 			ocm.changed = false
@@ -173,7 +195,7 @@ func (ocm *OfCycleMarquee) NextRender(currentBuffer []byte) {
 		return
 	}
 
-	endPos := ocm.pos + 20
+	endPos := ocm.pos + ocm.cols()
 	if endPos >= len(ocm.line) {
 		endPos = len(ocm.line)
 	}
@@ -196,8 +218,16 @@ func (ocm *OfCycleMarquee) NextRender(currentBuffer []byte) {
 	ocm.rendered = true
 }
 
+func (ocm *OfCycleMarquee) setWidth(width int) {
+	ocm.width = width
+	ocm.line = nil
+	ocm.nextLine = nil
+	ocm.pos = 0
+	ocm.rendered = false
+}
+
 func (ocm *OfCycleMarquee) setCurrentLine(line string) {
-	ocm.nextLine = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-20s", line))
+	ocm.nextLine = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-*s", ocm.cols(), line))
 	ocm.changed = true
 
 	if len(ocm.line) == 0 {
@@ -217,19 +247,27 @@ type OfTrimLine struct {
 
 func (otl *OfTrimLine) NextRender(currentBuffer []byte) {
 	if otl.changed {
-		copy(currentBuffer[:], otl.line[0:20])
+		copy(currentBuffer[:], otl.line[0:otl.cols()])
 		otl.changed = false
 	}
 }
 
+func (otl *OfTrimLine) setWidth(width int) {
+	otl.width = width
+	otl.line = nil
+	otl.changed = false
+}
+
 func (otl *OfTrimLine) setCurrentLine(line string) {
-	otl.line = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-20s", line))
+	otl.line = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-*s", otl.cols(), line))
 	otl.changed = true
 }
 
 type OfCustomStylePerLine struct {
 	BaseOverflowStyle
 
+	geometry Geometry
+
 	line1 NoWrapOverflowStyle
 	line2 NoWrapOverflowStyle
 	line3 NoWrapOverflowStyle
@@ -238,20 +276,41 @@ type OfCustomStylePerLine struct {
 
 func NewOverflowCustomStylePerLine(l1, l2, l3, l4 NoWrapOverflowStyle) *OfCustomStylePerLine {
 	return &OfCustomStylePerLine{
-		line1: l1,
-		line2: l2,
-		line3: l3,
-		line4: l4,
+		geometry: DefaultGeometry,
+		line1:    l1,
+		line2:    l2,
+		line3:    l3,
+		line4:    l4,
 	}
 }
 
-func (ocsp *OfCustomStylePerLine) NextRender(currentBuffer *CharLcdBuffer) []byte {
-	ocsp.line1.NextRender(currentBuffer[:20])
-	ocsp.line2.NextRender(currentBuffer[40:60])
-	ocsp.line3.NextRender(currentBuffer[20:40])
-	ocsp.line4.NextRender(currentBuffer[60:80])
+func (ocsp *OfCustomStylePerLine) lines() []NoWrapOverflowStyle {
+	return []NoWrapOverflowStyle{ocsp.line1, ocsp.line2, ocsp.line3, ocsp.line4}
+}
+
+// NextRender only renders as many lines as the panel has rows, rows past
+// the fourth stay blank.
+func (ocsp *OfCustomStylePerLine) NextRender(currentBuffer []byte) []byte {
+	cols, rows := ocsp.geometry.Cols, ocsp.geometry.Rows
+
+	for r, line := range ocsp.lines() {
+		if r >= rows {
+			break
+		}
+
+		offset := RowOffset(cols, rows, r)
+		line.NextRender(currentBuffer[offset : offset+cols])
+	}
 
-	return currentBuffer[:]
+	return currentBuffer
+}
+
+func (ocsp *OfCustomStylePerLine) setGeometry(geometry Geometry) {
+	ocsp.geometry = geometry
+
+	for _, line := range ocsp.lines() {
+		line.setWidth(geometry.Cols)
+	}
 }
 
 func (ocsp *OfCustomStylePerLine) setLine1(line string) {
@@ -273,54 +332,140 @@ func (ocsp *OfCustomStylePerLine) setLine4(line string) error {
 	return nil
 }
 
+// OfWrapSpanLines starts every line on a row of its own and wraps the
+// text that does not fit onto the rows below, pushing the lines after it
+// down. Whatever runs past the last row is cut.
 type OfWrapSpanLines struct {
 	BaseOverflowStyle
 
+	geometry Geometry
+	lines    [4]string
 	line     []byte
 	lchanged bool
 }
 
 func NewOverflowWrapSpanLines() *OfWrapSpanLines {
-	return &OfWrapSpanLines{}
+	return &OfWrapSpanLines{geometry: DefaultGeometry}
 }
 
-func (owl *OfWrapSpanLines) NextRender(currentBuffer *CharLcdBuffer) []byte {
+func (owl *OfWrapSpanLines) NextRender(currentBuffer []byte) []byte {
 	if owl.lchanged {
-		bytes := []byte(owl.line)[:CharLcdDimension]
-
-		// line 1
-		copy(currentBuffer[0:], bytes[0:20])
-		// line 3
-		copy(currentBuffer[20:], bytes[40:60])
-		// line 2
-		copy(currentBuffer[40:], bytes[20:40])
-		// line 4
-		copy(currentBuffer[60:], bytes[60:80])
+		cols, rows := owl.geometry.Cols, owl.geometry.Rows
+		bytes := []byte(owl.line)[:cols*rows]
+
+		// The text runs top to bottom, the frame is in DDRAM order.
+		for r := range rows {
+			copy(currentBuffer[RowOffset(cols, rows, r):], bytes[r*cols:(r+1)*cols])
+		}
 	}
 
-	return currentBuffer[:]
+	return currentBuffer
+}
+
+func (owl *OfWrapSpanLines) setGeometry(geometry Geometry) {
+	owl.geometry = geometry
+	owl.lines = [4]string{}
+	owl.line = nil
+	owl.lchanged = false
 }
 
 func (owl *OfWrapSpanLines) setLine1(line string) {
-	owl.line = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-[1]*s", CharLcdDimension, line))
+	owl.setLine(0, line)
+}
+
+func (owl *OfWrapSpanLines) setLine2(line string) error {
+	owl.setLine(1, line)
+	return nil
+}
+
+func (owl *OfWrapSpanLines) setLine3(line string) error {
+	owl.setLine(2, line)
+	return nil
+}
+
+func (owl *OfWrapSpanLines) setLine4(line string) error {
+	owl.setLine(3, line)
+	return nil
+}
+
+// setLine lays the lines out again, empty lines at the end take no rows
+// so a long first line can still span the whole display.
+func (owl *OfWrapSpanLines) setLine(idx int, line string) {
+	owl.lines[idx] = line
+
+	cols, rows := owl.geometry.Cols, owl.geometry.Rows
+
+	last := len(owl.lines) - 1
+	for last > 0 && owl.lines[last] == "" {
+		last--
+	}
+
+	var text strings.Builder
+	for _, line := range owl.lines[:last+1] {
+		spanned := max(1, (utf8.RuneCountInString(line)+cols-1)/cols)
+		fmt.Fprintf(&text, "%-*s", spanned*cols, line)
+	}
+
+	owl.line = ReplaceRuneWithLCDCharMap(fmt.Sprintf("%-*s", cols*rows, text.String()))
 	owl.lchanged = true
 }
 
-const CharLcdDimension = 4 * 20
+const (
+	DefaultBacklight = 255
+	DefaultContrast  = 128
+)
 
-type CharLcdBuffer [CharLcdDimension]byte
+type Lighting struct {
+	Backlight int
+	Contrast  int
+}
 
 type Buffer struct {
-	internal        CharLcdBuffer
+	internal        []byte
+	geometry        Geometry
 	overflowContext OverflowStyle
+
+	lighting        Lighting
+	lightingChanged bool
 }
 
 func NewBuffer(style OverflowStyle) *Buffer {
-	return &Buffer{overflowContext: style}
+	db := &Buffer{
+		overflowContext: style,
+		lighting:        Lighting{Backlight: DefaultBacklight, Contrast: DefaultContrast},
+	}
+
+	db.SetGeometry(DefaultGeometry)
+
+	return db
+}
+
+func (db *Buffer) Geometry() Geometry {
+	return db.geometry
+}
+
+// SetGeometry resizes the buffer for another panel, lines have to be set
+// again before they show up.
+func (db *Buffer) SetGeometry(geometry Geometry) {
+	if db.geometry == geometry {
+		return
+	}
+
+	db.geometry = geometry
+	db.internal = bytes.Repeat([]byte{' '}, geometry.Cols*geometry.Rows)
+	db.overflowContext.setGeometry(geometry)
+}
+
+// SetStyle swaps the overflow style, lines have to be set again before
+// they show up.
+func (db *Buffer) SetStyle(style OverflowStyle) {
+	db.overflowContext = style
+	db.internal = bytes.Repeat([]byte{' '}, db.geometry.Cols*db.geometry.Rows)
+	style.setGeometry(db.geometry)
 }
 
 func (db *Buffer) NextRender() []byte {
-	return db.overflowContext.NextRender(&db.internal)
+	return db.overflowContext.NextRender(db.internal)
 }
 
 func (db *Buffer) SetLine1(line fmt.Stringer) {
@@ -338,3 +483,31 @@ func (db *Buffer) SetLine3(line fmt.Stringer) error {
 func (db *Buffer) SetLine4(line fmt.Stringer) error {
 	return db.overflowContext.setLine4(line.String())
 }
+
+func (db *Buffer) SetBacklight(level int) {
+	if db.lighting.Backlight != level {
+		db.lighting.Backlight = level
+		db.lightingChanged = true
+	}
+}
+
+func (db *Buffer) SetContrast(level int) {
+	if db.lighting.Contrast != level {
+		db.lighting.Contrast = level
+		db.lightingChanged = true
+	}
+}
+
+// RefreshLighting has the lighting applied again, e.g. after the display
+// lost it.
+func (db *Buffer) RefreshLighting() {
+	db.lightingChanged = true
+}
+
+// LightingChanged returns the lighting to apply when it changed since the last call.
+func (db *Buffer) LightingChanged() (Lighting, bool) {
+	changed := db.lightingChanged
+	db.lightingChanged = false
+
+	return db.lighting, changed
+}
@@ -0,0 +1,187 @@
+package display
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite golden files in testdata with the current output.")
+
+type staticLine string
+
+func (sl staticLine) String() string {
+	return string(sl)
+}
+
+type goldenCase struct {
+	name  string
+	style func() OverflowStyle
+	lines [4]string
+	steps int
+
+	// geometry defaults to DefaultGeometry.
+	geometry Geometry
+
+	// When changeAt is set, lines are replaced by changed before that step.
+	changeAt int
+	changed  [4]string
+}
+
+func perLine(styles ...NoWrapOverflowStyle) func() OverflowStyle {
+	return func() OverflowStyle {
+		return NewOverflowCustomStylePerLine(styles[0], styles[1], styles[2], styles[3])
+	}
+}
+
+func setLines(t *testing.T, buffer *Buffer, lines [4]string) {
+	t.Helper()
+
+	buffer.SetLine1(staticLine(lines[0]))
+
+	for idx, setLine := range []func(fmt.Stringer) error{buffer.SetLine2, buffer.SetLine3, buffer.SetLine4} {
+		if lines[idx+1] == "" {
+			continue
+		}
+
+		if err := setLine(staticLine(lines[idx+1])); err != nil {
+			t.Fatalf("setting line %d: %v", idx+2, err)
+		}
+	}
+}
+
+func renderFrames(t *testing.T, gc goldenCase) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+
+	geometry := gc.geometry
+	if geometry == (Geometry{}) {
+		geometry = DefaultGeometry
+	}
+
+	buffer := NewBuffer(gc.style())
+	buffer.SetGeometry(geometry)
+	setLines(t, buffer, gc.lines)
+
+	for step := range gc.steps {
+		if gc.changeAt > 0 && step == gc.changeAt {
+			setLines(t, buffer, gc.changed)
+		}
+
+		fmt.Fprintf(&out, "frame %d\n", step)
+		for _, row := range FrameRows(buffer.NextRender(), geometry.Cols, geometry.Rows) {
+			fmt.Fprintf(&out, "|%s|\n", DecodeLCDCharMap(row))
+		}
+	}
+
+	return out.Bytes()
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file, run with -update to create it: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("frames differ from %s, run with -update if this is intended\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+func TestOverflowStylesGolden(t *testing.T) {
+	long := "the quick brown fox jumps over the lazy dog"
+
+	cases := []goldenCase{
+		{
+			name:  "trim_line",
+			style: perLine(&OfTrimLine{}, &OfTrimLine{}, &OfTrimLine{}, &OfTrimLine{}),
+			lines: [4]string{"short", long, "exactly twenty chars", "アイウエオ 25ºC"},
+			steps: 3,
+		},
+		{
+			name:  "endless_marquee",
+			style: perLine(&OfEndlessMarquee{rate: 1}, &OfEndlessMarquee{rate: 1}, &OfEndlessMarquee{rate: 3}, &OfEndlessMarquee{rate: 1}),
+			lines: [4]string{long, "short", long, "exactly twenty chars"},
+			steps: 60,
+		},
+		{
+			name:     "endless_marquee_change",
+			style:    perLine(&OfEndlessMarquee{rate: 1}, &OfTrimLine{}, &OfTrimLine{}, &OfTrimLine{}),
+			lines:    [4]string{"first line that scrolls", "two", "three", "four"},
+			steps:    60,
+			changeAt: 10,
+			changed:  [4]string{"second line, also scrolling", "two", "three", "four"},
+		},
+		{
+			name:  "cycle_marquee",
+			style: perLine(&OfCycleMarquee{rate: 1}, &OfCycleMarquee{rate: 1}, &OfCycleMarquee{rate: 2}, &OfCycleMarquee{rate: 1}),
+			lines: [4]string{"twenty four chars long!!", "short", long, "exactly twenty chars"},
+			steps: 40,
+		},
+		{
+			name:  "wrap_span_lines",
+			style: func() OverflowStyle { return NewOverflowWrapSpanLines() },
+			lines: [4]string{long + ", " + long + ", and then some more"},
+			steps: 2,
+		},
+		{
+			name: "custom_per_line",
+			style: func() OverflowStyle {
+				lines, err := TryParseCustomStyle("t,em:2,cm,t")
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				return NewOverflowCustomStylePerLine(lines[0], lines[1], lines[2], lines[3])
+			},
+			lines: [4]string{"2024-01-02  15:04:05", long, "a bit more than twenty", "eth0 ~ 192.168.1.2/24"},
+			steps: 30,
+		},
+		{
+			name:     "custom_per_line_16x2",
+			style:    perLine(&OfTrimLine{}, &OfEndlessMarquee{rate: 1}, &OfTrimLine{}, &OfTrimLine{}),
+			lines:    [4]string{"2024-01-02 15:04", "clear sky over the bay", "not shown", "not shown"},
+			steps:    10,
+			geometry: Geometry{Cols: 16, Rows: 2},
+		},
+		{
+			name:  "wrap_span_lines_page",
+			style: func() OverflowStyle { return NewOverflowWrapSpanLines() },
+			lines: [4]string{"2024-01-02  15:04:05", "light rain, 18ºC, wind 3m/s", "cpu 12%", "mem 40%"},
+			steps: 1,
+		},
+		{
+			name:     "wrap_span_lines_40x2",
+			style:    func() OverflowStyle { return NewOverflowWrapSpanLines() },
+			lines:    [4]string{long + ", " + long},
+			steps:    1,
+			geometry: Geometry{Cols: 40, Rows: 2},
+		},
+	}
+
+	for _, gc := range cases {
+		t.Run(gc.name, func(t *testing.T) {
+			checkGolden(t, gc.name, renderFrames(t, gc))
+		})
+	}
+}
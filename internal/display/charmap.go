@@ -2,6 +2,7 @@ package display
 
 import (
 	"bytes"
+	"strings"
 )
 
 var (
@@ -84,6 +85,10 @@ func init() {
 
 	CharMap["”"] = []byte{0xde}
 	CharMap["º"] = []byte{0xdf}
+
+	CharMap["→"] = []byte{0x7e}
+	CharMap["←"] = []byte{0x7f}
+	CharMap["█"] = []byte{0xff}
 }
 
 func ReplaceRuneWithLCDCharMap(original string) []byte {
@@ -95,3 +100,41 @@ func ReplaceRuneWithLCDCharMap(original string) []byte {
 
 	return buff
 }
+
+// DecodeLCDCharMap turns ROM codes back into printable text, it is the
+// reverse of ReplaceRuneWithLCDCharMap for the A00 (Japanese) ROM.
+// Katakana decode into their halfwidth forms so each code takes exactly
+// one terminal cell.
+func DecodeLCDCharMap(codes []byte) string {
+	var sb strings.Builder
+
+	for _, code := range codes {
+		sb.WriteRune(decodeA00(code))
+	}
+
+	return sb.String()
+}
+
+func decodeA00(code byte) rune {
+	switch {
+	case code < 0x08:
+		// CGRAM slots, the glyph is only known by the device.
+		return '▒'
+	case code == 0x5c:
+		return '¥'
+	case code == 0x7e:
+		return '→'
+	case code == 0x7f:
+		return '←'
+	case code == 0xdf:
+		return '°'
+	case code == 0xff:
+		return '█'
+	case code >= 0x20 && code < 0x7e:
+		return rune(code)
+	case code >= 0xa1 && code < 0xdf:
+		return rune(0xff61 + int(code) - 0xa1)
+	}
+
+	return '?'
+}
@@ -0,0 +1,33 @@
+package display
+
+type Geometry struct {
+	Cols, Rows int
+}
+
+var DefaultGeometry = Geometry{Cols: 20, Rows: 4}
+
+// RowOffset returns where the visual row r starts inside a frame rendered
+// for a cols x rows panel. Frames follow the HD44780 DDRAM order, so on a
+// 4 lines panel they carry line 1, line 3, line 2 and then line 4.
+func RowOffset(cols, rows, r int) int {
+	bankRows := (rows + 1) / 2
+
+	return (r%2)*bankRows*cols + (r/2)*cols
+}
+
+// FrameRows splits a frame into its visual rows, top to bottom.
+func FrameRows(frame []byte, cols, rows int) [][]byte {
+	result := make([][]byte, rows)
+
+	for r := range rows {
+		offset := RowOffset(cols, rows, r)
+		if offset+cols > len(frame) {
+			result[r] = []byte{}
+			continue
+		}
+
+		result[r] = frame[offset : offset+cols]
+	}
+
+	return result
+}
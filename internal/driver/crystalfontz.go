@@ -0,0 +1,73 @@
+package driver
+
+import (
+	"bytes"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+const (
+	cfHideCursor = 0x04
+	cfClear      = 0x0c
+	cfBacklight  = 0x0e
+	cfContrast   = 0x0f
+	cfGoto       = 0x11
+	cfScrollOff  = 0x14
+	cfWrapOff    = 0x18
+
+	// cfCustomChars is where the custom characters sit in the character
+	// set, codes below 0x20 are control codes.
+	cfCustomChars = 0x80
+)
+
+// Crystalfontz speaks the control code command set of the CFA-632 and
+// CFA-634 serial displays. Unlike Matrix Orbital there is no prefix,
+// codes below 0x20 are commands.
+type Crystalfontz struct {
+	geometry display.Geometry
+}
+
+func NewCrystalfontz(geometry display.Geometry) *Crystalfontz {
+	return &Crystalfontz{geometry: geometry}
+}
+
+func (cf *Crystalfontz) Init() []byte {
+	return []byte{cfHideCursor, cfScrollOff, cfWrapOff, cfClear}
+}
+
+func (cf *Crystalfontz) Frame(frame []byte) []byte {
+	var buf bytes.Buffer
+
+	for row, codes := range display.FrameRows(frame, cf.geometry.Cols, cf.geometry.Rows) {
+		buf.Write([]byte{cfGoto, 0, byte(row)})
+
+		for _, code := range codes {
+			switch {
+			case code < 0x08:
+				code += cfCustomChars
+			case code < 0x20:
+				code = ' '
+			}
+
+			buf.WriteByte(code)
+		}
+	}
+
+	return buf.Bytes()
+}
+
+func (cf *Crystalfontz) Clear() []byte {
+	return []byte{cfClear}
+}
+
+func (cf *Crystalfontz) Backlight(level int) []byte {
+	return []byte{cfBacklight, percent(level)}
+}
+
+func (cf *Crystalfontz) Contrast(level int) []byte {
+	return []byte{cfContrast, percent(level)}
+}
+
+func (cf *Crystalfontz) String() string {
+	return "crystalfontz"
+}
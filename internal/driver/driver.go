@@ -0,0 +1,79 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+var (
+	ErrUnknownDriver = errors.New("driver: error, unknown driver, use szb, matrixorbital or crystalfontz")
+)
+
+// Driver turns what szb shows into the bytes a display controller
+// understands. Frames come in the HD44780 DDRAM order of the szb
+// protocol and hold A00 ROM codes. Only Szb prompts and answers, the
+// other displays take what is sent without a word.
+type Driver interface {
+	// Init puts the display in a known state right after connecting.
+	Init() []byte
+	Frame(frame []byte) []byte
+	Clear() []byte
+	// Backlight takes 0 (off) to 255 (full on).
+	Backlight(level int) []byte
+	// Contrast takes 0 to 255.
+	Contrast(level int) []byte
+	String() string
+}
+
+// Parse picks a driver by name for a display of geometry.
+func Parse(name string, geometry display.Geometry) (Driver, error) {
+	switch name {
+	case "szb":
+		return Szb{}, nil
+	case "matrixorbital":
+		return NewMatrixOrbital(geometry), nil
+	case "crystalfontz":
+		return NewCrystalfontz(geometry), nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownDriver, name)
+}
+
+// Szb speaks the line protocol of the szb firmware.
+type Szb struct{}
+
+func (Szb) Init() []byte {
+	return nil
+}
+
+func (Szb) Frame(frame []byte) []byte {
+	return protocol.DisplayCommand(frame)
+}
+
+func (Szb) Clear() []byte {
+	return protocol.ClearCommand()
+}
+
+func (Szb) Backlight(level int) []byte {
+	return protocol.BacklightCommand(level)
+}
+
+func (Szb) Contrast(level int) []byte {
+	return protocol.ContrastCommand(level)
+}
+
+func (Szb) String() string {
+	return "szb"
+}
+
+// percent scales a 0-255 level to the 0-100 some controllers take.
+func percent(level int) byte {
+	return byte(min(max(level, 0), 255) * 100 / 255)
+}
+
+func clamp(level int) byte {
+	return byte(min(max(level, 0), 255))
+}
@@ -0,0 +1,69 @@
+package driver
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+var twoLines = display.Geometry{Cols: 4, Rows: 2}
+
+func TestMatrixOrbital(t *testing.T) {
+	mo := NewMatrixOrbital(twoLines)
+
+	frame := []byte{'a', 'b', 0xfe, 'd', 0x01, 'x', 'y', 'z'}
+	want := []byte{
+		0xfe, 0x47, 1, 1, 'a', 'b', ' ', 'd',
+		0xfe, 0x47, 1, 2, 0x01, 'x', 'y', 'z',
+	}
+	if got := mo.Frame(frame); !bytes.Equal(got, want) {
+		t.Errorf("Frame = % x, want % x", got, want)
+	}
+
+	if got := mo.Backlight(0); !bytes.Equal(got, []byte{0xfe, 0x46}) {
+		t.Errorf("Backlight(0) = % x", got)
+	}
+
+	if got := mo.Backlight(128); !bytes.Equal(got, []byte{0xfe, 0x42, 0, 0xfe, 0x99, 128}) {
+		t.Errorf("Backlight(128) = % x", got)
+	}
+}
+
+func TestCrystalfontz(t *testing.T) {
+	cf := NewCrystalfontz(twoLines)
+
+	frame := []byte{'a', 'b', 'c', 'd', 0x01, 0x10, 'y', 'z'}
+	want := []byte{
+		0x11, 0, 0, 'a', 'b', 'c', 'd',
+		0x11, 0, 1, 0x81, ' ', 'y', 'z',
+	}
+	if got := cf.Frame(frame); !bytes.Equal(got, want) {
+		t.Errorf("Frame = % x, want % x", got, want)
+	}
+
+	if got := cf.Backlight(255); !bytes.Equal(got, []byte{0x0e, 100}) {
+		t.Errorf("Backlight(255) = % x", got)
+	}
+
+	if got := cf.Contrast(128); !bytes.Equal(got, []byte{0x0f, 50}) {
+		t.Errorf("Contrast(128) = % x", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, name := range []string{"szb", "matrixorbital", "crystalfontz"} {
+		drv, err := Parse(name, twoLines)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", name, err)
+		}
+
+		if drv.String() != name {
+			t.Errorf("Parse(%q) = %s", name, drv)
+		}
+	}
+
+	if _, err := Parse("hitachi", twoLines); err == nil {
+		t.Error("Parse of an unknown driver should fail")
+	}
+}
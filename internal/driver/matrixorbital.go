@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"bytes"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+const (
+	moPrefix         = 0xfe
+	moClear          = 0x58
+	moGoto           = 0x47
+	moBacklightOn    = 0x42
+	moBacklightOff   = 0x46
+	moBrightness     = 0x99
+	moContrast       = 0x50
+	moAutoScrollOff  = 0x52
+	moWrapOff        = 0x44
+	moBlockCursorOff = 0x54
+	moUnderlineOff   = 0x4b
+)
+
+// MatrixOrbital speaks the 0xFE prefixed command set of Matrix Orbital
+// LK/VK serial displays and the many backpacks copying it.
+type MatrixOrbital struct {
+	geometry display.Geometry
+}
+
+func NewMatrixOrbital(geometry display.Geometry) *MatrixOrbital {
+	return &MatrixOrbital{geometry: geometry}
+}
+
+func (mo *MatrixOrbital) Init() []byte {
+	return []byte{
+		moPrefix, moAutoScrollOff,
+		moPrefix, moWrapOff,
+		moPrefix, moBlockCursorOff,
+		moPrefix, moUnderlineOff,
+		moPrefix, moClear,
+	}
+}
+
+// Frame moves to the start of every row before writing it, the display
+// wraps rows in its own order.
+func (mo *MatrixOrbital) Frame(frame []byte) []byte {
+	var buf bytes.Buffer
+
+	for row, codes := range display.FrameRows(frame, mo.geometry.Cols, mo.geometry.Rows) {
+		buf.Write([]byte{moPrefix, moGoto, 1, byte(row + 1)})
+
+		// A stray prefix byte would start a command.
+		buf.Write(bytes.ReplaceAll(codes, []byte{moPrefix}, []byte{' '}))
+	}
+
+	return buf.Bytes()
+}
+
+func (mo *MatrixOrbital) Clear() []byte {
+	return []byte{moPrefix, moClear}
+}
+
+func (mo *MatrixOrbital) Backlight(level int) []byte {
+	if level <= 0 {
+		return []byte{moPrefix, moBacklightOff}
+	}
+
+	// 0 minutes keeps it on for good.
+	return []byte{moPrefix, moBacklightOn, 0, moPrefix, moBrightness, clamp(level)}
+}
+
+func (mo *MatrixOrbital) Contrast(level int) []byte {
+	return []byte{moPrefix, moContrast, clamp(level)}
+}
+
+func (mo *MatrixOrbital) String() string {
+	return "matrixorbital"
+}
@@ -0,0 +1,191 @@
+package input
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	tokenButton  = "btn:"
+	tokenEncoder = "enc:"
+)
+
+type Event interface {
+	ImplEvent()
+}
+
+type ButtonAction int
+
+const (
+	ButtonDown ButtonAction = iota
+	ButtonUp
+	ButtonLong
+	// ButtonPress is not sent by the device, the Dispatcher makes it up
+	// when a button goes up without having been held for long.
+	ButtonPress
+)
+
+var buttonActions = map[string]ButtonAction{
+	"down": ButtonDown,
+	"up":   ButtonUp,
+	"long": ButtonLong,
+}
+
+func (ba ButtonAction) String() string {
+	switch ba {
+	case ButtonDown:
+		return "down"
+	case ButtonUp:
+		return "up"
+	case ButtonLong:
+		return "long"
+	case ButtonPress:
+		return "press"
+	}
+
+	return "unknown"
+}
+
+// ButtonEvent comes from tokens like `btn:1:down`, `btn:1:up` and `btn:1:long`.
+type ButtonEvent struct {
+	Button int
+	Action ButtonAction
+}
+
+func (ButtonEvent) ImplEvent() {}
+
+// EncoderEvent comes from tokens like `enc:+1` and `enc:-2`.
+type EncoderEvent struct {
+	Delta int
+}
+
+func (EncoderEvent) ImplEvent() {}
+
+// Parse turns a device token into an event, tokens that are not input
+// events are reported as not ok.
+func Parse(token string) (Event, bool) {
+	if rest, ok := strings.CutPrefix(token, tokenButton); ok {
+		button, action, ok := strings.Cut(rest, ":")
+		if !ok {
+			return nil, false
+		}
+
+		number, err := strconv.Atoi(button)
+		if err != nil {
+			return nil, false
+		}
+
+		act, ok := buttonActions[action]
+		if !ok {
+			return nil, false
+		}
+
+		return ButtonEvent{Button: number, Action: act}, true
+	}
+
+	if rest, ok := strings.CutPrefix(token, tokenEncoder); ok {
+		delta, err := strconv.Atoi(rest)
+		if err != nil {
+			return nil, false
+		}
+
+		return EncoderEvent{Delta: delta}, true
+	}
+
+	return nil, false
+}
+
+type buttonKey struct {
+	button int
+	action ButtonAction
+}
+
+// Dispatcher hands events over to the handlers registered for them.
+type Dispatcher struct {
+	buttons    map[buttonKey][]func()
+	encoders   []func(delta int)
+	any        []func(Event)
+	intercepts []func(Event) bool
+
+	// held are the buttons down and not yet held for long.
+	held map[int]bool
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		buttons: make(map[buttonKey][]func()),
+		held:    make(map[int]bool),
+	}
+}
+
+func (d *Dispatcher) OnButton(button int, action ButtonAction, handler func()) {
+	key := buttonKey{button, action}
+	d.buttons[key] = append(d.buttons[key], handler)
+}
+
+func (d *Dispatcher) OnEncoder(handler func(delta int)) {
+	d.encoders = append(d.encoders, handler)
+}
+
+// OnAny sees every event from the device before the specific handlers do.
+func (d *Dispatcher) OnAny(handler func(Event)) {
+	d.any = append(d.any, handler)
+}
+
+// Intercept sees events after OnAny handlers, an interceptor returning
+// true keeps the event from the button and encoder handlers.
+func (d *Dispatcher) Intercept(handler func(Event) bool) {
+	d.intercepts = append(d.intercepts, handler)
+}
+
+// Dispatch hands event over, followed by a ButtonPress when it lets go
+// of a button that was not held for long. Every press starts with
+// ButtonDown, so a short press is told from a long one on ButtonUp.
+func (d *Dispatcher) Dispatch(event Event) {
+	for _, handler := range d.any {
+		handler(event)
+	}
+
+	d.deliver(event)
+
+	if btn, ok := event.(ButtonEvent); ok && d.track(btn) {
+		d.deliver(ButtonEvent{Button: btn.Button, Action: ButtonPress})
+	}
+}
+
+// track follows the buttons held, it reports whether btn ends a short
+// press.
+func (d *Dispatcher) track(btn ButtonEvent) bool {
+	switch btn.Action {
+	case ButtonDown:
+		d.held[btn.Button] = true
+	case ButtonLong:
+		delete(d.held, btn.Button)
+	case ButtonUp:
+		held := d.held[btn.Button]
+		delete(d.held, btn.Button)
+
+		return held
+	}
+
+	return false
+}
+
+func (d *Dispatcher) deliver(event Event) {
+	for _, handler := range d.intercepts {
+		if handler(event) {
+			return
+		}
+	}
+
+	switch ev := event.(type) {
+	case ButtonEvent:
+		for _, handler := range d.buttons[buttonKey{ev.Button, ev.Action}] {
+			handler()
+		}
+	case EncoderEvent:
+		for _, handler := range d.encoders {
+			handler(ev.Delta)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package input
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		token string
+		want  Event
+	}{
+		{"btn:1:down", ButtonEvent{Button: 1, Action: ButtonDown}},
+		{"btn:2:up", ButtonEvent{Button: 2, Action: ButtonUp}},
+		{"btn:1:long", ButtonEvent{Button: 1, Action: ButtonLong}},
+		{"enc:+1", EncoderEvent{Delta: 1}},
+		{"enc:-3", EncoderEvent{Delta: -3}},
+	}
+
+	for _, c := range cases {
+		got, ok := Parse(c.token)
+		if !ok || got != c.want {
+			t.Errorf("Parse(%q) = %v, %v, want %v", c.token, got, ok, c.want)
+		}
+	}
+
+	for _, token := range []string{"$>:", "btn:1", "btn:x:down", "btn:1:twice", "enc:left"} {
+		if event, ok := Parse(token); ok {
+			t.Errorf("Parse(%q) = %v, want no event", token, event)
+		}
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	var (
+		seen  int
+		pages int
+	)
+
+	dispatcher := NewDispatcher()
+	dispatcher.OnAny(func(Event) { seen++ })
+	dispatcher.OnButton(1, ButtonDown, func() { pages++ })
+	dispatcher.OnEncoder(func(delta int) { pages += delta })
+
+	dispatcher.Dispatch(ButtonEvent{Button: 1, Action: ButtonDown})
+	dispatcher.Dispatch(ButtonEvent{Button: 1, Action: ButtonUp})
+	dispatcher.Dispatch(EncoderEvent{Delta: -2})
+
+	if seen != 3 || pages != -1 {
+		t.Errorf("seen %d events and moved %d pages, want 3 and -1", seen, pages)
+	}
+}
+
+func TestIntercept(t *testing.T) {
+	var (
+		intercepting bool
+		pages        int
+	)
+
+	dispatcher := NewDispatcher()
+	dispatcher.OnButton(1, ButtonDown, func() { pages++ })
+	dispatcher.Intercept(func(Event) bool { return intercepting })
+
+	dispatcher.Dispatch(ButtonEvent{Button: 1, Action: ButtonDown})
+	intercepting = true
+	dispatcher.Dispatch(ButtonEvent{Button: 1, Action: ButtonDown})
+
+	if pages != 1 {
+		t.Errorf("moved %d pages, want 1", pages)
+	}
+}
+
+func TestPress(t *testing.T) {
+	var pressed, held int
+
+	dispatcher := NewDispatcher()
+	dispatcher.OnButton(1, ButtonPress, func() { pressed++ })
+	dispatcher.OnButton(1, ButtonLong, func() { held++ })
+
+	for _, action := range []ButtonAction{
+		ButtonDown, ButtonUp, // press
+		ButtonDown, ButtonLong, ButtonUp, // hold
+		ButtonUp, // up without down
+	} {
+		dispatcher.Dispatch(ButtonEvent{Button: 1, Action: action})
+	}
+
+	if pressed != 1 || held != 1 {
+		t.Errorf("pressed %d and held %d times, want 1 and 1", pressed, held)
+	}
+}
@@ -11,6 +11,20 @@ type KickstartFunc[T any] func(*Context[T]) error
 type Context[T any] struct {
 	AppHandler T
 	Next       LoopState
+
+	done <-chan struct{}
+}
+
+// NewContext makes a context outside of Exec, e.g. to drive the lifecycle
+// functions from tests.
+func NewContext[T any](done <-chan struct{}) *Context[T] {
+	return &Context[T]{done: done}
+}
+
+// Done is closed once the app is asked to stop, blocking operations can
+// select on it to give up early.
+func (ctx *Context[T]) Done() <-chan struct{} {
+	return ctx.done
 }
 
 type App[T any] struct {
@@ -89,14 +103,14 @@ func exec[T any](app *App[T]) error {
 		close(stopRun)
 	}()
 
-	ctx := Context[T]{}
+	ctx := NewContext[T](stopRun)
 
-	if err := app.initFn(&ctx); err != nil {
+	if err := app.initFn(ctx); err != nil {
 		return err
 	}
 
 	if app.afterInitFn != nil {
-		if err := app.afterInitFn(&ctx); err != nil {
+		if err := app.afterInitFn(ctx); err != nil {
 			return err
 		}
 	}
@@ -109,7 +123,7 @@ LOOP:
 		default:
 		}
 
-		err := app.loopFn(&ctx)
+		err := app.loopFn(ctx)
 		if err != nil {
 			return err
 		}
@@ -124,7 +138,7 @@ LOOP:
 	}
 
 	if app.afterLoopFn != nil {
-		return app.afterLoopFn(&ctx)
+		return app.afterLoopFn(ctx)
 	}
 
 	return nil
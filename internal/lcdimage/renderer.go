@@ -0,0 +1,224 @@
+package lcdimage
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+const (
+	glyphCols = 5
+	glyphRows = 8
+
+	cgramSlots = 8
+)
+
+var (
+	ErrUnknownROM  = errors.New("lcdimage: error, unknown character ROM")
+	ErrFrameDelays = errors.New("lcdimage: error, frames and delays differ in number")
+)
+
+// Glyph is a 5x8 dot pattern, one byte per row with bit 4 as the leftmost dot.
+type Glyph [glyphRows]byte
+
+var placeholder = Glyph{0x1f, 0x11, 0x11, 0x11, 0x11, 0x11, 0x1f, 0x00}
+
+type Theme struct {
+	Backlight color.RGBA
+	Unlit     color.RGBA
+	Lit       color.RGBA
+}
+
+var (
+	ThemeYellowGreen = Theme{
+		Backlight: color.RGBA{0x9c, 0xc0, 0x1e, 0xff},
+		Unlit:     color.RGBA{0x8c, 0xae, 0x1a, 0xff},
+		Lit:       color.RGBA{0x1e, 0x2a, 0x08, 0xff},
+	}
+	ThemeBlue = Theme{
+		Backlight: color.RGBA{0x1c, 0x3c, 0xe0, 0xff},
+		Unlit:     color.RGBA{0x24, 0x48, 0xf0, 0xff},
+		Lit:       color.RGBA{0xe8, 0xf0, 0xff, 0xff},
+	}
+
+	roms = map[string]*[256]Glyph{
+		"A00": &romA00,
+	}
+)
+
+// ROM returns the glyphs of the named character ROM, indexed by code.
+func ROM(name string) (*[256]Glyph, error) {
+	rom, ok := roms[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownROM, name)
+	}
+
+	return rom, nil
+}
+
+// Renderer draws frames the way the panel shows them, dot by dot.
+type Renderer struct {
+	Cols, Rows int
+	ROM        string
+	Theme      Theme
+
+	// DotSize is the size of one dot in pixels, DotGap is how many of
+	// those pixels are left out between dots.
+	DotSize int
+	DotGap  int
+
+	// CGRAM holds the custom glyphs for codes 0-7, codes 8-15 mirror them.
+	CGRAM [cgramSlots]Glyph
+}
+
+func NewRenderer(cols, rows int) *Renderer {
+	return &Renderer{
+		Cols:    cols,
+		Rows:    rows,
+		ROM:     "A00",
+		Theme:   ThemeYellowGreen,
+		DotSize: 4,
+		DotGap:  1,
+	}
+}
+
+// Render draws frame with the glyphs of r.ROM. Codes above ASCII the ROM
+// has no glyph for are drawn as placeholder boxes, so they do not pass
+// for spaces.
+func (r *Renderer) Render(frame []byte) (*image.Paletted, error) {
+	rom, err := ROM(r.ROM)
+	if err != nil {
+		return nil, err
+	}
+
+	// One dot of spacing between cells, two dots of margin around the panel.
+	width := (r.Cols*(glyphCols+1) + 3) * r.DotSize
+	height := (r.Rows*(glyphRows+1) + 3) * r.DotSize
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, width, height),
+		color.Palette{r.Theme.Backlight, r.Theme.Unlit, r.Theme.Lit},
+	)
+
+	for row, codes := range display.FrameRows(frame, r.Cols, r.Rows) {
+		for col, code := range codes {
+			glyph := rom[code]
+			switch {
+			case code < 2*cgramSlots:
+				glyph = r.CGRAM[code%cgramSlots]
+			case code > 0x7f && glyph == Glyph{}:
+				glyph = placeholder
+			}
+
+			r.drawGlyph(img, glyph, 2+col*(glyphCols+1), 2+row*(glyphRows+1))
+		}
+	}
+
+	return img, nil
+}
+
+func (r *Renderer) drawGlyph(img *image.Paletted, glyph Glyph, dotX, dotY int) {
+	for y := range glyphRows {
+		for x := range glyphCols {
+			colorIdx := uint8(1)
+			if glyph[y]&(1<<(glyphCols-1-x)) != 0 {
+				colorIdx = 2
+			}
+
+			r.drawDot(img, (dotX+x)*r.DotSize, (dotY+y)*r.DotSize, colorIdx)
+		}
+	}
+}
+
+func (r *Renderer) drawDot(img *image.Paletted, px, py int, colorIdx uint8) {
+	size := r.DotSize - r.DotGap
+	if size < 1 {
+		size = 1
+	}
+
+	for y := py; y < py+size; y++ {
+		for x := px; x < px+size; x++ {
+			img.SetColorIndex(x, y, colorIdx)
+		}
+	}
+}
+
+func (r *Renderer) WritePNG(w io.Writer, frame []byte) error {
+	img, err := r.Render(frame)
+	if err != nil {
+		return err
+	}
+
+	return png.Encode(w, img)
+}
+
+// WritePNGSequence writes every frame as dir/<prefix>-0001.png, dir/<prefix>-0002.png, and so on.
+func (r *Renderer) WritePNGSequence(dir, prefix string, frames [][]byte) error {
+	for idx, frame := range frames {
+		err := r.writePNGFile(filepath.Join(dir, fmt.Sprintf("%s-%04d.png", prefix, idx+1)), frame)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Renderer) writePNGFile(path string, frame []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return r.WritePNG(file, frame)
+}
+
+// WriteGIF writes frames as a looping animated GIF, each frame is shown
+// for its delay. Frames on for less than a hundredth of a second are left
+// out, and ones on for longer than one GIF image can show are repeated.
+func (r *Renderer) WriteGIF(w io.Writer, frames [][]byte, delays []time.Duration) error {
+	if len(delays) != len(frames) {
+		return ErrFrameDelays
+	}
+
+	var (
+		anim    = &gif.GIF{}
+		elapsed time.Duration
+		written int
+	)
+
+	for idx, frame := range frames {
+		elapsed += delays[idx]
+
+		// Counted from the start, so rounding does not add up.
+		delay := int(elapsed/(10*time.Millisecond)) - written
+		if delay <= 0 {
+			continue
+		}
+
+		written += delay
+
+		img, err := r.Render(frame)
+		if err != nil {
+			return err
+		}
+
+		for ; delay > 0; delay -= math.MaxUint16 {
+			anim.Image = append(anim.Image, img)
+			anim.Delay = append(anim.Delay, min(delay, math.MaxUint16))
+		}
+	}
+
+	return gif.EncodeAll(w, anim)
+}
@@ -0,0 +1,154 @@
+package lcdimage
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"image/gif"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "Rewrite golden files in testdata with the current output.")
+
+func TestRenderUnknownROM(t *testing.T) {
+	renderer := NewRenderer(16, 2)
+	renderer.ROM = "A02"
+
+	if _, err := renderer.Render([]byte("hello world     szb             ")); !errors.Is(err, ErrUnknownROM) {
+		t.Errorf("drawing with a ROM without a table = %v, want ErrUnknownROM", err)
+	}
+}
+
+func TestRenderPlaceholder(t *testing.T) {
+	renderer := NewRenderer(1, 1)
+
+	for _, code := range []byte{0x80, 0xa0, 0xe4, 0xfe} {
+		img, err := renderer.Render([]byte{code})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lit := false
+		for _, idx := range img.Pix {
+			lit = lit || idx == 2
+		}
+
+		if !lit {
+			t.Errorf("code %#x is drawn blank", code)
+		}
+	}
+}
+
+func TestWriteGIF(t *testing.T) {
+	frames := [][]byte{
+		[]byte("12:30:05        cpu  4%         "),
+		[]byte("12:30:06        cpu 42%         "),
+		[]byte("12:30:07        \x00 done         "),
+	}
+
+	renderer := NewRenderer(16, 2)
+	renderer.CGRAM[0] = Glyph{0x00, 0x01, 0x03, 0x16, 0x1c, 0x08, 0x00, 0x00}
+
+	var out bytes.Buffer
+	if err := renderer.WriteGIF(&out, frames, []time.Duration{time.Second, time.Second, time.Second}); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "clock.gif", out.Bytes())
+}
+
+func TestWriteGIFDelays(t *testing.T) {
+	frames := [][]byte{[]byte("a "), []byte("b "), []byte("c "), []byte("d ")}
+	delays := []time.Duration{
+		5 * time.Millisecond,
+		1 * time.Second,
+		3 * time.Hour,
+		time.Second + 5*time.Millisecond,
+	}
+
+	var out bytes.Buffer
+	if err := NewRenderer(2, 1).WriteGIF(&out, frames, delays); err != nil {
+		t.Fatal(err)
+	}
+
+	anim, err := gif.DecodeAll(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first frame is too short to show, three hours take 17 images.
+	want := []int{100}
+	for left := 3 * 60 * 60 * 100; left > 0; left -= math.MaxUint16 {
+		want = append(want, min(left, math.MaxUint16))
+	}
+	want = append(want, 101)
+
+	if !slices.Equal(anim.Delay, want) {
+		t.Errorf("delays = %v, want %v", anim.Delay, want)
+	}
+
+	if err := NewRenderer(2, 1).WriteGIF(&out, frames, delays[:1]); !errors.Is(err, ErrFrameDelays) {
+		t.Errorf("WriteGIF with too few delays = %v, want ErrFrameDelays", err)
+	}
+}
+
+func TestWritePNGSequence(t *testing.T) {
+	dir := t.TempDir()
+	frames := [][]byte{[]byte("one "), []byte("two ")}
+
+	if err := NewRenderer(4, 1).WritePNGSequence(dir, "szb", frames); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"szb-0001.png", "szb-0002.png"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		img, err := png.Decode(file)
+		file.Close()
+
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		// Two dots of margin and one between cells, 4 pixels a dot.
+		if size := img.Bounds().Size(); size.X != (4*6+3)*4 || size.Y != (9+3)*4 {
+			t.Errorf("%s is %v", name, size)
+		}
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file, run with -update to create it: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("image differs from %s, run with -update if this is intended", path)
+	}
+}
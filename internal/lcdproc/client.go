@@ -0,0 +1,232 @@
+package lcdproc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+const (
+	DefaultDialTimeout = 5 * time.Second
+)
+
+var (
+	ErrBadGreeting = errors.New("lcdproc: error, server did not answer hello with connect")
+	ErrRefused     = errors.New("lcdproc: error, server refused")
+)
+
+// Client speaks the client side of the LCDproc protocol to an LCDd.
+type Client struct {
+	Geometry display.Geometry
+
+	mu      sync.Mutex
+	conn    net.Conn
+	replies *bufio.Scanner
+}
+
+// Dial connects to the LCDd at addr and learns the size of its display
+// from the hello reply. name is how szb shows up in LCDd.
+func Dial(addr, name string) (*Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, DefaultDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &Client{conn: conn, replies: bufio.NewScanner(conn)}
+
+	greeting, err := client.command("hello")
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	client.Geometry, err = parseGreeting(greeting)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err := client.Command("client_set", "-name", name); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// parseGreeting reads the size out of
+// `connect LCDproc 0.5.9 protocol 0.3 lcd wid 20 hgt 4 cellwid 5 cellhgt 8`.
+func parseGreeting(greeting string) (display.Geometry, error) {
+	fields := strings.Fields(greeting)
+	if len(fields) == 0 || fields[0] != "connect" {
+		return display.Geometry{}, fmt.Errorf("%w: %s", ErrBadGreeting, greeting)
+	}
+
+	var geometry display.Geometry
+
+	for idx := 0; idx+1 < len(fields); idx++ {
+		switch fields[idx] {
+		case "wid":
+			geometry.Cols, _ = strconv.Atoi(fields[idx+1])
+		case "hgt":
+			geometry.Rows, _ = strconv.Atoi(fields[idx+1])
+		}
+	}
+
+	if geometry.Cols <= 0 || geometry.Rows <= 0 {
+		return geometry, fmt.Errorf("%w: %s", ErrBadGreeting, greeting)
+	}
+
+	return geometry, nil
+}
+
+// Command sends one command and waits for it to succeed, arguments with
+// spaces or quotes are quoted.
+func (c *Client) Command(args ...string) error {
+	quoted := make([]string, len(args))
+	for idx, arg := range args {
+		quoted[idx] = arg
+		if arg == "" || strings.ContainsAny(arg, " \t\"{}\\") {
+			quoted[idx] = Quote(arg)
+		}
+	}
+
+	_, err := c.command(strings.Join(quoted, " "))
+
+	return err
+}
+
+// command sends line and returns the reply to it. Messages LCDd sends on
+// its own, like listen, ignore and key, are skipped.
+func (c *Client) command(line string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, err := fmt.Fprintf(c.conn, "%s\n", line); err != nil {
+		return "", err
+	}
+
+	for c.replies.Scan() {
+		reply := c.replies.Text()
+
+		switch {
+		case reply == "success", strings.HasPrefix(reply, "connect "):
+			return reply, nil
+		case strings.HasPrefix(reply, "huh?"):
+			return "", fmt.Errorf("%w: %s", ErrRefused, strings.TrimSpace(strings.TrimPrefix(reply, "huh?")))
+		}
+	}
+
+	if err := c.replies.Err(); err != nil {
+		return "", err
+	}
+
+	return "", io.EOF
+}
+
+func (c *Client) Close() error {
+	fmt.Fprintln(c.conn, "bye")
+
+	return c.conn.Close()
+}
+
+// Output shows szb frames on an LCDd as one screen, with a string widget
+// per row. Only rows that changed are sent.
+type Output struct {
+	client *Client
+	screen string
+	rows   []string
+}
+
+func NewOutput(client *Client, screen string) (*Output, error) {
+	commands := [][]string{
+		{"screen_add", screen},
+		{"screen_set", screen, "-name", screen, "-priority", "foreground", "-heartbeat", "off"},
+	}
+
+	for row := range client.Geometry.Rows {
+		commands = append(commands, []string{"widget_add", screen, rowWidget(row), "string"})
+	}
+
+	for _, cmd := range commands {
+		if err := client.Command(cmd...); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Output{
+		client: client,
+		screen: screen,
+		rows:   make([]string, client.Geometry.Rows),
+	}, nil
+}
+
+func rowWidget(row int) string {
+	return "r" + strconv.Itoa(row+1)
+}
+
+func (o *Output) Render(frame []byte) error {
+	geometry := o.client.Geometry
+
+	for row, codes := range display.FrameRows(frame, geometry.Cols, geometry.Rows) {
+		text := toLatin1(display.DecodeLCDCharMap(codes))
+		if text == o.rows[row] {
+			continue
+		}
+
+		if err := o.client.Command("widget_set", o.screen, rowWidget(row), "1", strconv.Itoa(row+1), text); err != nil {
+			return err
+		}
+
+		o.rows[row] = text
+	}
+
+	return nil
+}
+
+func (o *Output) Clear() error {
+	geometry := o.client.Geometry
+
+	return o.Render([]byte(strings.Repeat(" ", geometry.Cols*geometry.Rows)))
+}
+
+func (o *Output) SetBacklight(level int) error {
+	state := "on"
+	if level == 0 {
+		state = "off"
+	}
+
+	return o.client.Command("backlight", state)
+}
+
+// toLatin1 fits text into the ISO-8859-1 bytes LCDd drivers expect,
+// characters outside of it are swapped for a close ASCII one or a
+// question mark.
+func toLatin1(text string) string {
+	out := make([]byte, 0, len(text))
+
+	for _, r := range text {
+		switch {
+		case r == '→':
+			out = append(out, '>')
+		case r == '←':
+			out = append(out, '<')
+		case r == '█':
+			out = append(out, '#')
+		case r <= 0xff:
+			out = append(out, byte(r))
+		default:
+			out = append(out, '?')
+		}
+	}
+
+	return string(out)
+}
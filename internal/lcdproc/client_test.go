@@ -0,0 +1,134 @@
+package lcdproc_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/fudanchii/szb/internal/lcdproc"
+)
+
+// fakeLCDd answers like an LCDd with a 16x2 display and keeps every
+// command it gets. It says listen on its own after screen_set, the way
+// LCDd does when a screen goes up.
+type fakeLCDd struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeLCDd(t *testing.T) *fakeLCDd {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeLCDd{listener: listener}
+	go fake.serve()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return fake
+}
+
+func (fake *fakeLCDd) serve() {
+	conn, err := fake.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	lines := bufio.NewScanner(conn)
+	for lines.Scan() {
+		line := lines.Text()
+
+		fake.mu.Lock()
+		fake.commands = append(fake.commands, line)
+		fake.mu.Unlock()
+
+		switch {
+		case line == "hello":
+			fmt.Fprintln(conn, "connect LCDproc 0.5.9 protocol 0.3 lcd wid 16 hgt 2 cellwid 5 cellhgt 8")
+		case strings.HasPrefix(line, "screen_set"):
+			fmt.Fprintln(conn, "listen szb")
+			fmt.Fprintln(conn, "success")
+		case strings.HasPrefix(line, "widget_set szb r9"):
+			fmt.Fprintln(conn, "huh? Invalid widget id")
+		default:
+			fmt.Fprintln(conn, "success")
+		}
+	}
+}
+
+func (fake *fakeLCDd) took() []string {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+
+	took := fake.commands
+	fake.commands = nil
+
+	return took
+}
+
+func TestOutput(t *testing.T) {
+	fake := newFakeLCDd(t)
+
+	client, err := lcdproc.Dial(fake.listener.Addr().String(), "szb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if client.Geometry.Cols != 16 || client.Geometry.Rows != 2 {
+		t.Fatalf("Geometry = %+v", client.Geometry)
+	}
+
+	output, err := lcdproc.NewOutput(client, "szb")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fake.took()
+
+	frame := []byte(fmt.Sprintf("%-16s%-16s", "12:30 Sat", "cpu 12%"))
+	frame[15] = 0x7e
+
+	if err := output.Render(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		`widget_set szb r1 1 1 "12:30 Sat      >"`,
+		`widget_set szb r2 1 2 "cpu 12%         "`,
+	}
+	if took := fake.took(); !slices.Equal(took, want) {
+		t.Errorf("Render sent %q, want %q", took, want)
+	}
+
+	copy(frame[16:], "cpu 15%")
+	if err := output.Render(frame); err != nil {
+		t.Fatal(err)
+	}
+
+	want = []string{`widget_set szb r2 1 2 "cpu 15%         "`}
+	if took := fake.took(); !slices.Equal(took, want) {
+		t.Errorf("Render of a changed row sent %q, want %q", took, want)
+	}
+
+	if err := output.SetBacklight(0); err != nil {
+		t.Fatal(err)
+	}
+
+	if took := fake.took(); !slices.Equal(took, []string{"backlight off"}) {
+		t.Errorf("SetBacklight sent %q", took)
+	}
+
+	if err := client.Command("widget_set", "szb", "r9", "1", "1", "x"); err == nil {
+		t.Error("a refused command should fail")
+	}
+}
@@ -0,0 +1,80 @@
+package lcdproc
+
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	DefaultPort = 13666
+
+	ProtocolVersion = "0.3"
+	ServerVersion   = "0.5.9"
+
+	CellWidth  = 5
+	CellHeight = 8
+)
+
+var (
+	ErrUnterminated = errors.New("lcdproc: error, unterminated quote or brace")
+)
+
+// Split breaks a protocol line into arguments. Arguments are separated by
+// spaces, and "..." or {...} keep spaces in one argument. A backslash in
+// quotes takes the next character as is.
+func Split(line string) ([]string, error) {
+	args := []string{}
+
+	var (
+		sb      strings.Builder
+		inArg   bool
+		closing rune
+		escaped bool
+	)
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			sb.WriteRune(r)
+			escaped = false
+		case closing != 0 && r == '\\':
+			escaped = true
+		case closing != 0 && r == closing:
+			closing = 0
+		case closing != 0:
+			sb.WriteRune(r)
+		case r == '"':
+			closing = '"'
+			inArg = true
+		case r == '{':
+			closing = '}'
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\r' || r == '\n':
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if closing != 0 || escaped {
+		return nil, ErrUnterminated
+	}
+
+	if inArg {
+		args = append(args, sb.String())
+	}
+
+	return args, nil
+}
+
+// Quote wraps text so Split reads it back as one argument.
+func Quote(text string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	return `"` + replacer.Replace(text) + `"`
+}
@@ -0,0 +1,335 @@
+package lcdproc
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// scrollTick is the unit of scroller speeds, LCDd renders at 8Hz.
+	scrollTick = time.Second / 8
+
+	fullBlock = '█'
+)
+
+var (
+	ErrUnknownWidget     = errors.New("lcdproc: error, unknown widget")
+	ErrWidgetExists      = errors.New("lcdproc: error, widget already exists")
+	ErrUnknownWidgetType = errors.New("lcdproc: error, unknown widget type, use string, title, hbar, vbar, icon, scroller, num or frame")
+	ErrWidgetArgs        = errors.New("lcdproc: error, wrong widget arguments")
+	ErrScreenArgs        = errors.New("lcdproc: error, wrong screen arguments")
+)
+
+var widgetArgs = map[string]int{
+	"string":   3,
+	"title":    1,
+	"hbar":     3,
+	"vbar":     3,
+	"icon":     3,
+	"scroller": 7,
+	"num":      2,
+	"frame":    8,
+}
+
+// numericArgs are the positions of the numeric arguments of each widget
+// type.
+var numericArgs = map[string][]int{
+	"string":   {0, 1},
+	"hbar":     {0, 1, 2},
+	"vbar":     {0, 1, 2},
+	"icon":     {0, 1},
+	"scroller": {0, 1, 2, 3, 5},
+	"num":      {0, 1},
+	"frame":    {0, 1, 2, 3, 4, 5, 7},
+}
+
+// icons maps LCDproc icon names onto characters in the display ROM,
+// other icons show as a question mark.
+var icons = map[string]rune{
+	"BLOCK_FILLED":      fullBlock,
+	"ARROW_LEFT":        '←',
+	"ARROW_RIGHT":       '→',
+	"ELLIPSIS":          ':',
+	"STOP":              '#',
+	"PAUSE":             '=',
+	"PLAY":              '>',
+	"PLAYR":             '<',
+	"CHECKBOX_ON":       'X',
+	"CHECKBOX_OFF":      'O',
+	"SELECTOR_AT_LEFT":  '>',
+	"SELECTOR_AT_RIGHT": '<',
+}
+
+type widget struct {
+	id    string
+	kind  string
+	args  []string
+	since time.Time
+	// inFrame widgets are kept but not drawn, frames are not laid out.
+	inFrame bool
+}
+
+// num reads argument idx, which checkArgs made sure is a number.
+func (w *widget) num(idx int) int {
+	n, _ := strconv.Atoi(w.args[idx])
+	return n
+}
+
+// Screen is one client screen laid out on a cols by rows grid, coordinates
+// given by clients start at 1.
+type Screen struct {
+	ID string
+
+	cols, rows int
+
+	mu       sync.Mutex
+	name     string
+	priority string
+	widgets  []*widget
+}
+
+func NewScreen(id string, cols, rows int) *Screen {
+	return &Screen{ID: id, cols: cols, rows: rows, priority: "info"}
+}
+
+func (s *Screen) Priority() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.priority
+}
+
+// Set takes screen_set options, options szb has no use for (duration,
+// heartbeat, backlight and so on) are accepted and ignored.
+func (s *Screen) Set(args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for idx := 0; idx < len(args); idx++ {
+		option := strings.TrimPrefix(args[idx], "-")
+		if option == args[idx] || idx+1 >= len(args) {
+			return fmt.Errorf("%w: %s", ErrScreenArgs, args[idx])
+		}
+
+		idx++
+		value := args[idx]
+
+		switch option {
+		case "name":
+			s.name = value
+		case "priority":
+			s.priority = value
+		}
+	}
+
+	return nil
+}
+
+func (s *Screen) AddWidget(id, kind string, inFrame bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := widgetArgs[kind]; !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownWidgetType, kind)
+	}
+
+	if s.find(id) >= 0 {
+		return fmt.Errorf("%w: %s", ErrWidgetExists, id)
+	}
+
+	s.widgets = append(s.widgets, &widget{id: id, kind: kind, inFrame: inFrame})
+
+	return nil
+}
+
+func (s *Screen) SetWidget(id string, args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.find(id)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownWidget, id)
+	}
+
+	w := s.widgets[idx]
+	if err := checkArgs(w.kind, args); err != nil {
+		return err
+	}
+
+	w.args = args
+	w.since = time.Now()
+
+	return nil
+}
+
+func (s *Screen) DelWidget(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.find(id)
+	if idx < 0 {
+		return fmt.Errorf("%w: %s", ErrUnknownWidget, id)
+	}
+
+	s.widgets = append(s.widgets[:idx], s.widgets[idx+1:]...)
+
+	return nil
+}
+
+func (s *Screen) find(id string) int {
+	for idx, w := range s.widgets {
+		if w.id == id {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// checkArgs makes sure every argument of kind is there, and that the
+// numeric ones are numbers.
+func checkArgs(kind string, args []string) error {
+	if len(args) != widgetArgs[kind] {
+		return fmt.Errorf("%w: %s takes %d", ErrWidgetArgs, kind, widgetArgs[kind])
+	}
+
+	for _, idx := range numericArgs[kind] {
+		if _, err := strconv.Atoi(args[idx]); err != nil {
+			return fmt.Errorf("%w: %s is not a number", ErrWidgetArgs, args[idx])
+		}
+	}
+
+	return nil
+}
+
+// Render draws the widgets in the order they were added, one string per
+// row.
+func (s *Screen) Render(now time.Time) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	grid := make([][]rune, s.rows)
+	for row := range grid {
+		grid[row] = []rune(strings.Repeat(" ", s.cols))
+	}
+
+	put := func(x, y int, text string) {
+		if y < 0 || y >= s.rows {
+			return
+		}
+
+		for _, r := range text {
+			if x >= 0 && x < s.cols {
+				grid[y][x] = r
+			}
+			x++
+		}
+	}
+
+	for _, w := range s.widgets {
+		if w.args == nil || w.inFrame {
+			continue
+		}
+
+		num := w.num
+
+		switch w.kind {
+		case "string":
+			put(num(0)-1, num(1)-1, w.args[2])
+		case "title":
+			title := "## " + w.args[0] + " "
+			put(0, 0, title+strings.Repeat("#", max(0, s.cols-len([]rune(title)))))
+		case "hbar":
+			put(num(0)-1, num(1)-1, strings.Repeat(string(fullBlock), max(0, num(2)/CellWidth)))
+		case "vbar":
+			for cell := range max(0, num(2)/CellHeight) {
+				put(num(0)-1, num(1)-1-cell, string(fullBlock))
+			}
+		case "icon":
+			icon, ok := icons[w.args[2]]
+			if !ok {
+				icon = '?'
+			}
+
+			put(num(0)-1, num(1)-1, string(icon))
+		case "scroller":
+			s.renderScroller(w, now, put)
+		case "num":
+			digit := strconv.Itoa(num(1))
+			if num(1) == 10 {
+				digit = ":"
+			}
+
+			put(num(0)-1, s.rows/2, digit)
+		}
+	}
+
+	lines := make([]string, s.rows)
+	for row := range grid {
+		lines[row] = string(grid[row])
+	}
+
+	return lines
+}
+
+// renderScroller moves the text one step every speed ticks, a speed of 0
+// or less keeps it still. Horizontal (h) and marquee (m) scrollers run
+// the text through their row, vertical (v) ones page through it a
+// width at a time.
+func (s *Screen) renderScroller(w *widget, now time.Time, put func(x, y int, text string)) {
+	num := w.num
+
+	left, top, right, bottom := num(0)-1, num(1)-1, num(2)-1, num(3)-1
+	width := right - left + 1
+	height := bottom - top + 1
+	speed := num(5)
+	text := []rune(w.args[6])
+
+	if width <= 0 || height <= 0 {
+		return
+	}
+
+	step := 0
+	if speed > 0 {
+		step = int(now.Sub(w.since) / (time.Duration(speed) * scrollTick))
+	}
+
+	if w.args[4] == "v" {
+		chunks := []string{}
+		for start := 0; start < len(text); start += width {
+			chunks = append(chunks, string(text[start:min(start+width, len(text))]))
+		}
+
+		if len(chunks) <= height {
+			step = 0
+		}
+
+		for row := range height {
+			if row >= len(chunks) {
+				break
+			}
+
+			put(left, top+row, chunks[(step+row)%len(chunks)])
+		}
+
+		return
+	}
+
+	if len(text) <= width {
+		put(left, top, string(text))
+		return
+	}
+
+	loop := append(append([]rune{}, text...), ' ')
+	offset := step % len(loop)
+	visible := make([]rune, width)
+	for idx := range visible {
+		visible[idx] = loop[(offset+idx)%len(loop)]
+	}
+
+	put(left, top, string(visible))
+}
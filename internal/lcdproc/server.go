@@ -0,0 +1,342 @@
+package lcdproc
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/pages"
+)
+
+var (
+	ErrNoHello       = errors.New("lcdproc: error, say hello first")
+	ErrUnknownScreen = errors.New("lcdproc: error, unknown screen")
+	ErrScreenExists  = errors.New("lcdproc: error, screen already exists")
+	ErrMissingArgs   = errors.New("lcdproc: error, missing arguments")
+)
+
+// Sink is where client screens end up, as pages named lcdprocN:screen
+// after the connection.
+type Sink interface {
+	AddPage(page *pages.Page)
+	RemovePage(name string)
+	// ShowPage brings a screen up when a client raises its priority.
+	ShowPage(name string)
+}
+
+// Server speaks the LCDd side of the LCDproc protocol, so LCDproc clients
+// can put their screens on szb displays.
+type Server struct {
+	geometry func() display.Geometry
+	sink     Sink
+	listener net.Listener
+
+	mu      sync.Mutex
+	conns   map[net.Conn]struct{}
+	clients int
+	closed  bool
+	running sync.WaitGroup
+}
+
+// Listen sets the server up on addr (e.g. :13666), screens are laid out
+// on the geometry there is when the client says hello.
+func Listen(addr string, geometry func() display.Geometry, sink Sink) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		geometry: geometry,
+		sink:     sink,
+		listener: listener,
+		conns:    make(map[net.Conn]struct{}),
+	}, nil
+}
+
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Serve takes clients until Close.
+func (s *Server) Serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+
+			if closed {
+				return nil
+			}
+
+			return err
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+
+			return nil
+		}
+
+		s.clients++
+		s.conns[conn] = struct{}{}
+		s.running.Add(1)
+
+		c := &client{
+			server:  s,
+			conn:    conn,
+			id:      fmt.Sprintf("lcdproc%d", s.clients),
+			screens: make(map[string]*Screen),
+			pages:   make(map[string]*pages.Page),
+		}
+		s.mu.Unlock()
+
+		go func() {
+			defer s.running.Done()
+
+			c.serve()
+		}()
+	}
+}
+
+// Close stops taking clients and drops the connected ones along with
+// their screens.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.running.Wait()
+
+	return err
+}
+
+// client is one LCDproc connection. Its pages are named after id, which
+// no other client has, the name it gives itself only labels them.
+type client struct {
+	server   *Server
+	conn     net.Conn
+	id       string
+	name     string
+	hello    bool
+	geometry display.Geometry
+	screens  map[string]*Screen
+	pages    map[string]*pages.Page
+}
+
+func (c *client) serve() {
+	defer func() {
+		for id := range c.screens {
+			c.server.sink.RemovePage(c.pageName(id))
+		}
+
+		c.server.mu.Lock()
+		delete(c.server.conns, c.conn)
+		c.server.mu.Unlock()
+
+		c.conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(c.conn)
+	for scanner.Scan() {
+		args, err := Split(scanner.Text())
+		if err != nil {
+			c.reply("huh? %v", err)
+			continue
+		}
+
+		if len(args) == 0 {
+			continue
+		}
+
+		if args[0] == "bye" {
+			return
+		}
+
+		reply, err := c.handle(args[0], args[1:])
+		if err != nil {
+			c.reply("huh? %v", err)
+			continue
+		}
+
+		c.reply("%s", reply)
+	}
+}
+
+func (c *client) reply(format string, args ...any) {
+	fmt.Fprintf(c.conn, format+"\n", args...)
+}
+
+func (c *client) pageName(id string) string {
+	return c.id + ":" + id
+}
+
+func (c *client) pageLabel(id string) string {
+	name := c.name
+	if name == "" {
+		name = c.id
+	}
+
+	return name + ":" + id
+}
+
+func (c *client) handle(cmd string, args []string) (string, error) {
+	if cmd == "hello" {
+		c.hello = true
+		c.geometry = c.server.geometry()
+
+		return fmt.Sprintf(
+			"connect LCDproc %s protocol %s lcd wid %d hgt %d cellwid %d cellhgt %d",
+			ServerVersion, ProtocolVersion, c.geometry.Cols, c.geometry.Rows, CellWidth, CellHeight,
+		), nil
+	}
+
+	if !c.hello {
+		return "", ErrNoHello
+	}
+
+	switch cmd {
+	case "client_set":
+		for idx := 0; idx+1 < len(args); idx += 2 {
+			if args[idx] == "-name" {
+				c.name = args[idx+1]
+			}
+		}
+
+		for id, page := range c.pages {
+			page.SetLabel(c.pageLabel(id))
+		}
+	case "screen_add":
+		if len(args) < 1 {
+			return "", ErrMissingArgs
+		}
+
+		if _, ok := c.screens[args[0]]; ok {
+			return "", fmt.Errorf("%w: %s", ErrScreenExists, args[0])
+		}
+
+		screen := NewScreen(args[0], c.geometry.Cols, c.geometry.Rows)
+		page := c.newPage(screen)
+		c.screens[args[0]] = screen
+		c.pages[args[0]] = page
+		c.server.sink.AddPage(page)
+	case "screen_set":
+		screen, err := c.screen(args)
+		if err != nil {
+			return "", err
+		}
+
+		if err := screen.Set(args[1:]); err != nil {
+			return "", err
+		}
+
+		switch screen.Priority() {
+		case "foreground", "alert", "input":
+			c.server.sink.ShowPage(c.pageName(screen.ID))
+		}
+	case "screen_del":
+		screen, err := c.screen(args)
+		if err != nil {
+			return "", err
+		}
+
+		delete(c.screens, screen.ID)
+		delete(c.pages, screen.ID)
+		c.server.sink.RemovePage(c.pageName(screen.ID))
+	case "widget_add":
+		screen, err := c.screen(args)
+		if err != nil {
+			return "", err
+		}
+
+		if len(args) < 3 {
+			return "", ErrMissingArgs
+		}
+
+		inFrame := len(args) >= 5 && args[3] == "-in"
+		if err := screen.AddWidget(args[1], args[2], inFrame); err != nil {
+			return "", err
+		}
+	case "widget_set":
+		screen, err := c.screen(args)
+		if err != nil {
+			return "", err
+		}
+
+		if len(args) < 2 {
+			return "", ErrMissingArgs
+		}
+
+		if err := screen.SetWidget(args[1], args[2:]); err != nil {
+			return "", err
+		}
+	case "widget_del":
+		screen, err := c.screen(args)
+		if err != nil {
+			return "", err
+		}
+
+		if len(args) < 2 {
+			return "", ErrMissingArgs
+		}
+
+		if err := screen.DelWidget(args[1]); err != nil {
+			return "", err
+		}
+	case "info":
+		return fmt.Sprintf("szb %dx%d", c.geometry.Cols, c.geometry.Rows), nil
+	case "noop", "backlight", "output", "key_add", "key_del":
+		// Keys stay with the szb bindings and lighting with its schedule.
+	default:
+		return "", fmt.Errorf("invalid command %q", cmd)
+	}
+
+	return "success", nil
+}
+
+func (c *client) screen(args []string) (*Screen, error) {
+	if len(args) < 1 {
+		return nil, ErrMissingArgs
+	}
+
+	screen, ok := c.screens[args[0]]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownScreen, args[0])
+	}
+
+	return screen, nil
+}
+
+// newPage shows screen as a page, rows past the fourth are left out.
+func (c *client) newPage(screen *Screen) *pages.Page {
+	page := &pages.Page{Name: c.pageName(screen.ID)}
+	page.SetLabel(c.pageLabel(screen.ID))
+
+	for idx := range min(len(page.Lines), c.geometry.Rows) {
+		page.Lines[idx] = screenRow{screen: screen, row: idx}
+	}
+
+	return page
+}
+
+type screenRow struct {
+	screen *Screen
+	row    int
+}
+
+func (sr screenRow) String() string {
+	return strings.TrimRight(sr.screen.Render(time.Now())[sr.row], " ")
+}
@@ -0,0 +1,234 @@
+package lcdproc_test
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/lcdproc"
+	"github.com/fudanchii/szb/internal/pages"
+)
+
+type sink struct {
+	mu    sync.Mutex
+	pages map[string]*pages.Page
+	shown string
+}
+
+func (s *sink) AddPage(page *pages.Page) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pages[page.Name] = page
+}
+
+func (s *sink) RemovePage(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.pages, name)
+}
+
+func (s *sink) ShowPage(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.shown = name
+}
+
+func (s *sink) page(name string) *pages.Page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.pages[name]
+}
+
+func TestSplit(t *testing.T) {
+	args, err := lcdproc.Split(`widget_set s1 w1 1 2 "say \"hi\"" {two words}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"widget_set", "s1", "w1", "1", "2", `say "hi"`, "two words"}
+	if !slices.Equal(args, want) {
+		t.Errorf("Split = %q, want %q", args, want)
+	}
+
+	if _, err := lcdproc.Split(`widget_set "open`); err == nil {
+		t.Error("unterminated quote should fail")
+	}
+}
+
+func TestServer(t *testing.T) {
+	pageSink := &sink{pages: make(map[string]*pages.Page)}
+
+	server, err := lcdproc.Listen("127.0.0.1:0", func() display.Geometry {
+		return display.Geometry{Cols: 20, Rows: 4}
+	}, pageSink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve()
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	replies := bufio.NewScanner(conn)
+	send := func(line string) string {
+		fmt.Fprintln(conn, line)
+
+		if !replies.Scan() {
+			t.Fatalf("no reply to %q", line)
+		}
+
+		return replies.Text()
+	}
+
+	if reply := send("screen_add s1"); reply[:4] != "huh?" {
+		t.Errorf("screen_add before hello = %q", reply)
+	}
+
+	want := "connect LCDproc 0.5.9 protocol 0.3 lcd wid 20 hgt 4 cellwid 5 cellhgt 8"
+	if reply := send("hello"); reply != want {
+		t.Errorf("hello = %q", reply)
+	}
+
+	for _, line := range []string{
+		"client_set -name mon",
+		"screen_add s1",
+		"widget_add s1 title title",
+		"widget_add s1 load string",
+		"widget_add s1 bar hbar",
+		"widget_add s1 mark icon",
+		"widget_set s1 title {CPU}",
+		`widget_set s1 load 1 2 "Load 0.42"`,
+		"widget_set s1 bar 1 3 50",
+		"widget_set s1 mark 20 4 ARROW_RIGHT",
+		"screen_set s1 -priority foreground -duration 8",
+	} {
+		if reply := send(line); reply != "success" {
+			t.Fatalf("%s = %q", line, reply)
+		}
+	}
+
+	if reply := send("widget_set s1 load 1 x oops"); reply[:4] != "huh?" {
+		t.Errorf("widget_set with a bad x = %q", reply)
+	}
+
+	page := pageSink.page("lcdproc1:s1")
+	if page == nil {
+		t.Fatal("screen did not become a page")
+	}
+
+	if label := page.Label(); label != "mon:s1" {
+		t.Errorf("page label = %q, want the client name", label)
+	}
+
+	rows := []string{
+		"## CPU #############",
+		"Load 0.42",
+		"██████████",
+		"                   →",
+	}
+	for idx, row := range rows {
+		if got := page.Line(idx).String(); got != row {
+			t.Errorf("row %d = %q, want %q", idx, got, row)
+		}
+	}
+
+	if pageSink.shown != "lcdproc1:s1" {
+		t.Errorf("foreground screen not shown, shown = %q", pageSink.shown)
+	}
+
+	if reply := send("screen_del s1"); reply != "success" {
+		t.Errorf("screen_del = %q", reply)
+	}
+
+	if pageSink.page("lcdproc1:s1") != nil {
+		t.Error("deleted screen still has a page")
+	}
+
+	send("screen_add s2")
+	fmt.Fprintln(conn, "bye")
+
+	deadline := time.Now().Add(time.Second)
+	for pageSink.page("lcdproc1:s2") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("screens stay after the client left")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestServerClientNames(t *testing.T) {
+	pageSink := &sink{pages: make(map[string]*pages.Page)}
+
+	server, err := lcdproc.Listen("127.0.0.1:0", func() display.Geometry {
+		return display.Geometry{Cols: 20, Rows: 4}
+	}, pageSink)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go server.Serve()
+	defer server.Close()
+
+	dial := func(lines ...string) net.Conn {
+		t.Helper()
+
+		conn, err := net.Dial("tcp", server.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		replies := bufio.NewScanner(conn)
+		for _, line := range append([]string{"hello"}, lines...) {
+			fmt.Fprintln(conn, line)
+
+			if !replies.Scan() {
+				t.Fatalf("no reply to %q", line)
+			}
+		}
+
+		return conn
+	}
+
+	// Same name twice, and a screen added before the rename.
+	first := dial("screen_add early", "client_set -name lcdproc", "screen_add s1")
+	second := dial("client_set -name lcdproc", "screen_add s1")
+	defer second.Close()
+
+	if page := pageSink.page("lcdproc1:early"); page == nil || page.Label() != "lcdproc:early" {
+		t.Errorf("screen added before the rename = %v", page)
+	}
+
+	if pageSink.page("lcdproc1:s1") == nil || pageSink.page("lcdproc2:s1") == nil {
+		t.Fatal("clients with the same name share their pages")
+	}
+
+	first.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for pageSink.page("lcdproc1:early") != nil || pageSink.page("lcdproc1:s1") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("screens stay after the client left")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	if pageSink.page("lcdproc2:s1") == nil {
+		t.Error("a client leaving took the page of another client with it")
+	}
+}
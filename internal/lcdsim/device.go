@@ -0,0 +1,271 @@
+package lcdsim
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+var (
+	ErrDeviceClosed = errors.New("lcdsim: error, device is closed")
+)
+
+// Renderer receives whatever the simulated panel shows.
+type Renderer interface {
+	Render(frame []byte) error
+	Clear() error
+	SetBacklight(level int) error
+}
+
+const (
+	// PushWindow is how many commands the device buffers in push mode.
+	PushWindow = 4
+)
+
+// Discard is a Renderer for headless devices.
+var Discard Renderer = discard{}
+
+type discard struct{}
+
+func (discard) Render([]byte) error    { return nil }
+func (discard) Clear() error           { return nil }
+func (discard) SetBacklight(int) error { return nil }
+
+// Device emulates the firmware side of the serial protocol. It prompts for
+// a command, takes `display:` and `clr`, then prompts again once latency
+// has elapsed, the same way the Arduino paces the host. In push mode it
+// grants a credit back once latency has elapsed instead.
+type Device struct {
+	cols, rows int
+	latency    time.Duration
+	renderer   Renderer
+	caps       protocol.Capabilities
+
+	mu     sync.Mutex
+	ddram  []byte
+	inbuf  []byte
+	outbuf []byte
+	wake   chan struct{}
+	done   chan struct{}
+	closed bool
+	framed bool
+	push   bool
+}
+
+func NewDevice(cols, rows int, latency time.Duration, renderer Renderer) *Device {
+	dev := &Device{
+		cols:     cols,
+		rows:     rows,
+		latency:  latency,
+		renderer: renderer,
+		caps: protocol.Capabilities{
+			Version:   protocol.ProtocolVersion,
+			Cols:      cols,
+			Rows:      rows,
+			ROM:       "A00",
+			Backlight: true,
+			MaxRate:   20,
+			Framed:    true,
+		},
+		ddram: bytes.Repeat([]byte{' '}, cols*rows),
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+
+	// The firmware prompts right after it boots.
+	dev.outbuf = append(dev.outbuf, protocol.CmdPrompt+"\n"...)
+
+	return dev
+}
+
+func (dev *Device) Read(p []byte) (int, error) {
+	for {
+		dev.mu.Lock()
+		if len(dev.outbuf) > 0 {
+			n := copy(p, dev.outbuf)
+			dev.outbuf = dev.outbuf[n:]
+			dev.mu.Unlock()
+
+			return n, nil
+		}
+		dev.mu.Unlock()
+
+		select {
+		case <-dev.done:
+			return 0, io.EOF
+		case <-dev.wake:
+		}
+	}
+}
+
+func (dev *Device) Write(p []byte) (int, error) {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	if dev.closed {
+		return 0, ErrDeviceClosed
+	}
+
+	dev.inbuf = append(dev.inbuf, p...)
+
+	for {
+		cmd, ok := dev.nextCommand()
+		if !ok {
+			break
+		}
+
+		if err := dev.handleCommand(cmd); err != nil {
+			return len(p), err
+		}
+
+		switch {
+		case strings.HasPrefix(cmd.Name, protocol.CmdPush):
+			// Already answered with the whole window.
+		case dev.push:
+			time.AfterFunc(dev.latency, dev.credit)
+		default:
+			time.AfterFunc(dev.latency, dev.prompt)
+		}
+	}
+
+	dev.signal()
+
+	return len(p), nil
+}
+
+func (dev *Device) Close() error {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	if !dev.closed {
+		dev.closed = true
+		close(dev.done)
+	}
+
+	return nil
+}
+
+// Send makes the device say token, e.g. an input event like `btn:1:down`.
+func (dev *Device) Send(token string) {
+	dev.reply(token + "\n")
+}
+
+// SetCapabilities changes what the device answers to hello, a version
+// below 2 makes it behave like legacy firmware that ignores hello.
+func (dev *Device) SetCapabilities(caps protocol.Capabilities) {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	dev.caps = caps
+}
+
+// Screen returns the visual rows currently held in the virtual DDRAM.
+func (dev *Device) Screen() [][]byte {
+	dev.mu.Lock()
+	defer dev.mu.Unlock()
+
+	frame := bytes.Clone(dev.ddram)
+
+	return display.FrameRows(frame, dev.cols, dev.rows)
+}
+
+func (dev *Device) prompt() {
+	dev.reply(protocol.CmdPrompt + "\n")
+}
+
+func (dev *Device) credit() {
+	dev.reply(string(protocol.CreditReply(1)))
+}
+
+func (dev *Device) reply(token string) {
+	dev.mu.Lock()
+	dev.outbuf = append(dev.outbuf, token...)
+	dev.mu.Unlock()
+
+	dev.signal()
+}
+
+// signal wakes a Read waiting for output.
+func (dev *Device) signal() {
+	select {
+	case dev.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextCommand consumes one complete command from inbuf. In framed mode
+// every frame is answered with an ACK or NAK, only good frames give a
+// command, which then gets a prompt like any other.
+func (dev *Device) nextCommand() (protocol.Command, bool) {
+	if !dev.framed {
+		cmd, n := protocol.ParseCommand(dev.inbuf, len(dev.ddram))
+		if n == 0 {
+			return protocol.Command{}, false
+		}
+
+		dev.inbuf = dev.inbuf[n:]
+
+		return cmd, true
+	}
+
+	for {
+		frame, n := protocol.NextFrame(dev.inbuf)
+		dev.inbuf = dev.inbuf[n:]
+
+		if frame == nil {
+			return protocol.Command{}, false
+		}
+
+		seq, payload, err := protocol.DecodeFrame(frame)
+		dev.outbuf = append(dev.outbuf, protocol.AckReply(seq, err == nil)...)
+
+		if err != nil {
+			continue
+		}
+
+		cmd, _ := protocol.ParseCommand(append(payload, '\n'), len(dev.ddram))
+
+		return cmd, true
+	}
+}
+
+func (dev *Device) handleCommand(cmd protocol.Command) error {
+	switch cmd.Name {
+	case protocol.CmdDisplay:
+		copy(dev.ddram, cmd.Payload)
+
+		return dev.renderer.Render(bytes.Clone(dev.ddram))
+	case protocol.CmdClear:
+		copy(dev.ddram, bytes.Repeat([]byte{' '}, len(dev.ddram)))
+
+		return dev.renderer.Clear()
+	}
+
+	switch {
+	case strings.HasPrefix(cmd.Name, protocol.CmdHello) && dev.caps.Version >= protocol.ProtocolVersion:
+		dev.outbuf = append(dev.outbuf, protocol.CapsReply(dev.caps)...)
+	case strings.HasPrefix(cmd.Name, protocol.CmdFramed) && dev.caps.Framed:
+		dev.framed = true
+	case strings.HasPrefix(cmd.Name, protocol.CmdPush):
+		dev.push = true
+		dev.outbuf = append(dev.outbuf, protocol.CreditReply(PushWindow)...)
+	}
+
+	if level, ok := protocol.ParseLevel(cmd, protocol.CmdBacklight); ok {
+		if err := dev.renderer.SetBacklight(level); err != nil {
+			return err
+		}
+
+		return dev.renderer.Render(bytes.Clone(dev.ddram))
+	}
+
+	// Anything else, contrast included, has no visible effect here and only
+	// gets its prompt.
+	return nil
+}
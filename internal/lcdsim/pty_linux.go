@@ -0,0 +1,132 @@
+//go:build linux
+
+package lcdsim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// PTY runs a Device behind the master side of a pseudo-terminal, so
+// anything opening Path talks to it the way it would to the Arduino.
+type PTY struct {
+	*Device
+	Path string
+
+	master *os.File
+	// slave is held open so the master keeps working while no client has
+	// the port open, e.g. between reconnects.
+	slave *os.File
+}
+
+func OpenPTY(cols, rows int, latency time.Duration, renderer Renderer) (*PTY, error) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_NONBLOCK|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	number, err := unix.IoctlGetInt(fd, unix.TIOCGPTN)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	path := fmt.Sprintf("/dev/pts/%d", number)
+
+	slave, err := openRaw(path)
+	if err != nil {
+		master.Close()
+		return nil, err
+	}
+
+	pty := &PTY{
+		Device: NewDevice(cols, rows, latency, renderer),
+		Path:   path,
+		master: master,
+		slave:  slave,
+	}
+
+	go pty.fromHost()
+	go pty.toHost()
+
+	return pty, nil
+}
+
+// openRaw opens the slave side and turns off echo and line editing right
+// away, before the device prompts into it.
+func openRaw(path string) (*os.File, error) {
+	slave, err := os.OpenFile(path, os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	termios, err := unix.IoctlGetTermios(int(slave.Fd()), unix.TCGETS)
+	if err != nil {
+		slave.Close()
+		return nil, err
+	}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+
+	if err := unix.IoctlSetTermios(int(slave.Fd()), unix.TCSETS, termios); err != nil {
+		slave.Close()
+		return nil, err
+	}
+
+	return slave, nil
+}
+
+func (pty *PTY) fromHost() {
+	buf := make([]byte, 256)
+
+	for {
+		n, err := pty.master.Read(buf)
+		if n > 0 {
+			pty.Device.Write(buf[:n])
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (pty *PTY) toHost() {
+	buf := make([]byte, 256)
+
+	for {
+		n, err := pty.Device.Read(buf)
+		if n > 0 {
+			if _, err := pty.master.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (pty *PTY) Close() error {
+	return errors.Join(
+		pty.Device.Close(),
+		pty.master.Close(),
+		pty.slave.Close(),
+	)
+}
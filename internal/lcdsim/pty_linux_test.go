@@ -0,0 +1,51 @@
+package lcdsim_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/lcdsim"
+	"github.com/fudanchii/szb/internal/protocol"
+	"github.com/fudanchii/szb/internal/transport"
+)
+
+func TestPTY(t *testing.T) {
+	pty, err := lcdsim.OpenPTY(20, 4, time.Millisecond, lcdsim.Discard)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	defer pty.Close()
+
+	port := &transport.Serial{Device: pty.Path, Line: transport.DefaultSerialLine(115200)}
+
+	conn, err := port.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	caps, conn, err := protocol.Handshake(conn, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.Cols != 20 || caps.Rows != 4 {
+		t.Fatalf("caps = %+v, want 20x4", caps)
+	}
+
+	frame := bytes.Repeat([]byte{'x'}, 80)
+	copy(frame, "hello")
+
+	if _, err := conn.Write(protocol.DisplayCommand(frame)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := protocol.WaitPrompt(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(pty.Screen()[0]); got != "helloxxxxxxxxxxxxxxx" {
+		t.Errorf("first row shows %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package lcdsim
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/fudanchii/szb/internal/display"
+)
+
+const (
+	ansiPanel      = "\x1b[30;42m"
+	ansiPanelDim   = "\x1b[32;40m"
+	ansiPanelUnlit = "\x1b[90;40m"
+	ansiReset      = "\x1b[0m"
+
+	dimThreshold = 128
+)
+
+// Terminal draws frames as a boxed panel on an ANSI terminal, redrawing
+// in place on every frame.
+type Terminal struct {
+	out        io.Writer
+	cols, rows int
+	drawn      bool
+	backlight  int
+}
+
+func NewTerminal(out io.Writer, cols, rows int) *Terminal {
+	return &Terminal{out: out, cols: cols, rows: rows, backlight: 255}
+}
+
+func (t *Terminal) Render(frame []byte) error {
+	var sb strings.Builder
+
+	if t.drawn {
+		fmt.Fprintf(&sb, "\x1b[%dA", t.rows+2)
+	}
+
+	border := strings.Repeat("─", t.cols)
+
+	panel := ansiPanel
+	switch {
+	case t.backlight == 0:
+		panel = ansiPanelUnlit
+	case t.backlight < dimThreshold:
+		panel = ansiPanelDim
+	}
+
+	fmt.Fprintf(&sb, "\r┌%s┐\n", border)
+	for _, row := range display.FrameRows(frame, t.cols, t.rows) {
+		fmt.Fprintf(&sb, "\r│%s%-*s%s│\n", panel, t.cols, display.DecodeLCDCharMap(row), ansiReset)
+	}
+	fmt.Fprintf(&sb, "\r└%s┘\n", border)
+
+	t.drawn = true
+
+	_, err := io.WriteString(t.out, sb.String())
+
+	return err
+}
+
+func (t *Terminal) Clear() error {
+	return t.Render([]byte(strings.Repeat(" ", t.cols*t.rows)))
+}
+
+func (t *Terminal) SetBacklight(level int) error {
+	t.backlight = level
+
+	return nil
+}
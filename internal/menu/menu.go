@@ -0,0 +1,198 @@
+package menu
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	cursorMark = '>'
+)
+
+type Option struct {
+	Label string
+	Value string
+}
+
+// Item is one menu entry. Options lists what can be picked, Current tells
+// the value in use and Apply puts a picked value to use.
+type Item struct {
+	Label   string
+	Options func() []Option
+	Current func() string
+	Apply   func(value string) error
+}
+
+// Menu is a list of items with a cursor. Selecting an item starts picking
+// one of its options, selecting again applies it.
+type Menu struct {
+	mu      sync.Mutex
+	items   []Item
+	open    bool
+	cursor  int
+	top     int
+	editing bool
+	choice  int
+	options []Option
+}
+
+func New(items ...Item) *Menu {
+	return &Menu{items: items}
+}
+
+func (m *Menu) IsOpen() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.open
+}
+
+func (m *Menu) Open() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.open = true
+	m.cursor = 0
+	m.top = 0
+	m.editing = false
+}
+
+func (m *Menu) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.open = false
+	m.editing = false
+}
+
+// Move walks the cursor, or the option being picked, by delta with
+// wrap around.
+func (m *Menu) Move(delta int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.editing {
+		m.choice = wrap(m.choice+delta, len(m.options))
+		return
+	}
+
+	m.cursor = wrap(m.cursor+delta, len(m.items))
+}
+
+// Select starts picking an option for the item under the cursor, or
+// applies the option being picked. Apply runs without the menu locked,
+// it may take a while or use the menu itself.
+func (m *Menu) Select() error {
+	m.mu.Lock()
+
+	item := m.items[m.cursor]
+
+	if !m.editing {
+		m.options = item.Options()
+		if len(m.options) == 0 {
+			m.mu.Unlock()
+			return nil
+		}
+
+		current := item.Current()
+		m.choice = max(0, slices.IndexFunc(m.options, func(opt Option) bool {
+			return opt.Value == current
+		}))
+		m.editing = true
+		m.mu.Unlock()
+
+		return nil
+	}
+
+	m.editing = false
+	value := m.options[m.choice].Value
+	m.mu.Unlock()
+
+	return item.Apply(value)
+}
+
+// Back stops picking an option, or closes the menu when not picking.
+func (m *Menu) Back() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.editing {
+		m.editing = false
+		return
+	}
+
+	m.open = false
+}
+
+// Render lays the items out on rows lines of cols characters, scrolled
+// so the cursor stays in sight. The item under the cursor is marked, and
+// the option being picked shows in angle brackets.
+func (m *Menu) Render(cols, rows int) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.cursor < m.top {
+		m.top = m.cursor
+	}
+
+	if m.cursor >= m.top+rows {
+		m.top = m.cursor - rows + 1
+	}
+
+	lines := make([]string, rows)
+
+	for r := range rows {
+		idx := m.top + r
+		if idx >= len(m.items) {
+			break
+		}
+
+		item := m.items[idx]
+
+		mark := ' '
+		if idx == m.cursor {
+			mark = cursorMark
+		}
+
+		value := m.label(item, item.Current())
+		if idx == m.cursor && m.editing {
+			value = "<" + m.options[m.choice].Label + ">"
+		}
+
+		labelWidth := max(0, cols-1-utf8.RuneCountInString(value)-1)
+		line := fmt.Sprintf("%c%-*s %s", mark, labelWidth, truncate(item.Label, labelWidth), value)
+
+		lines[r] = truncate(line, cols)
+	}
+
+	return lines
+}
+
+func (m *Menu) label(item Item, value string) string {
+	for _, opt := range item.Options() {
+		if opt.Value == value {
+			return opt.Label
+		}
+	}
+
+	return value
+}
+
+// truncate cuts s to n characters, not bytes, so none is cut in half.
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+
+	return string([]rune(s)[:n])
+}
+
+func wrap(idx, count int) int {
+	if count == 0 {
+		return 0
+	}
+
+	return (idx%count + count) % count
+}
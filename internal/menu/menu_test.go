@@ -0,0 +1,110 @@
+package menu
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMenu(t *testing.T) {
+	level := "64"
+	applied := []string{}
+
+	levels := func() []Option {
+		return []Option{{Label: "low", Value: "64"}, {Label: "high", Value: "255"}}
+	}
+
+	menu := New(
+		Item{
+			Label:   "Backlight",
+			Options: levels,
+			Current: func() string { return level },
+			Apply: func(value string) error {
+				level = value
+				applied = append(applied, value)
+				return nil
+			},
+		},
+		Item{Label: "Page", Options: func() []Option { return nil }, Current: func() string { return "overview" }},
+		Item{Label: "Timezone", Options: func() []Option { return nil }, Current: func() string { return "UTC" }},
+	)
+
+	menu.Open()
+
+	want := []string{">Backlight       low", " Page       overview"}
+	if lines := menu.Render(20, 2); !slices.Equal(lines, want) {
+		t.Errorf("Render = %q, want %q", lines, want)
+	}
+
+	menu.Move(-1)
+
+	want = []string{" Page       overview", ">Timezone        UTC"}
+	if lines := menu.Render(20, 2); !slices.Equal(lines, want) {
+		t.Errorf("Render after wrap = %q, want %q", lines, want)
+	}
+
+	menu.Move(1)
+	menu.Select()
+	menu.Move(1)
+
+	want = []string{">Backlight    <high>", " Page       overview"}
+	if lines := menu.Render(20, 2); !slices.Equal(lines, want) {
+		t.Errorf("Render while picking = %q, want %q", lines, want)
+	}
+
+	if err := menu.Select(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(applied, []string{"255"}) {
+		t.Errorf("applied = %q", applied)
+	}
+
+	menu.Back()
+
+	if menu.IsOpen() {
+		t.Error("menu still open after Back")
+	}
+}
+
+func TestMenuRunesAndReentry(t *testing.T) {
+	var menu *Menu
+
+	menu = New(Item{
+		Label:   "Zeitzone für Uhr",
+		Options: func() []Option { return []Option{{Label: "Zürich", Value: "Europe/Zurich"}} },
+		Current: func() string { return "Europe/Zurich" },
+		Apply: func(string) error {
+			// Closing from Apply used to deadlock on the menu lock.
+			menu.Close()
+			return nil
+		},
+	})
+
+	menu.Open()
+
+	want := []string{">Zeitzone f Zürich", "> Zür"}
+	for idx, cols := range []int{18, 5} {
+		if lines := menu.Render(cols, 1); !slices.Equal(lines, want[idx:idx+1]) {
+			t.Errorf("Render(%d) = %q, want %q", cols, lines, want[idx:idx+1])
+		}
+	}
+
+	menu.Select()
+
+	applied := make(chan error, 1)
+	go func() { applied <- menu.Select() }()
+
+	select {
+	case err := <-applied:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Select holds the menu locked while applying")
+	}
+
+	if menu.IsOpen() {
+		t.Error("menu still open after Apply closed it")
+	}
+}
@@ -0,0 +1,184 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Packet types of MQTT 3.1.1, the high nibble of the first byte.
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+	protocolLevel     = 4
+	connectCleanStart = 0x02
+	connectPassword   = 0x40
+	connectUsername   = 0x80
+	subscribeFlags    = 0x02
+	subackFailure     = 0x80
+
+	// maxPacket bounds what a broker can make us buffer.
+	maxPacket = 1 << 20
+)
+
+var (
+	ErrMalformed     = errors.New("mqtt: error, malformed packet")
+	ErrPacketTooLong = errors.New("mqtt: error, packet too long")
+)
+
+type packet struct {
+	kind  byte
+	flags byte
+	body  []byte
+}
+
+func readPacket(r *bufio.Reader) (packet, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return packet{}, err
+	}
+
+	size := 0
+	for shift := 0; ; shift += 7 {
+		if shift > 21 {
+			return packet{}, ErrMalformed
+		}
+
+		digit, err := r.ReadByte()
+		if err != nil {
+			return packet{}, err
+		}
+
+		size |= int(digit&0x7f) << shift
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+
+	if size > maxPacket {
+		return packet{}, fmt.Errorf("%w: %d bytes", ErrPacketTooLong, size)
+	}
+
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return packet{}, err
+	}
+
+	return packet{kind: header >> 4, flags: header & 0x0f, body: body}, nil
+}
+
+func (p packet) encode() []byte {
+	buf := []byte{p.kind<<4 | p.flags}
+
+	size := len(p.body)
+	for {
+		digit := byte(size & 0x7f)
+		size >>= 7
+
+		if size > 0 {
+			digit |= 0x80
+		}
+
+		buf = append(buf, digit)
+		if size == 0 {
+			break
+		}
+	}
+
+	return append(buf, p.body...)
+}
+
+func appendString(buf []byte, text string) []byte {
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(text)))
+	return append(buf, text...)
+}
+
+// readString takes a length prefixed string from the start of buf.
+func readString(buf []byte) (string, []byte, error) {
+	if len(buf) < 2 {
+		return "", nil, ErrMalformed
+	}
+
+	size := int(binary.BigEndian.Uint16(buf))
+	if len(buf) < 2+size {
+		return "", nil, ErrMalformed
+	}
+
+	return string(buf[2 : 2+size]), buf[2+size:], nil
+}
+
+func connectPacket(clientID, username, password string, keepAlive uint16) packet {
+	flags := byte(connectCleanStart)
+	if username != "" {
+		flags |= connectUsername
+	}
+
+	if password != "" {
+		flags |= connectPassword
+	}
+
+	body := appendString(nil, "MQTT")
+	body = append(body, protocolLevel, flags)
+	body = binary.BigEndian.AppendUint16(body, keepAlive)
+	body = appendString(body, clientID)
+
+	if username != "" {
+		body = appendString(body, username)
+	}
+
+	if password != "" {
+		body = appendString(body, password)
+	}
+
+	return packet{kind: packetConnect, body: body}
+}
+
+// subscribePacket asks for every filter at QoS 0, a display only cares
+// about the latest value.
+func subscribePacket(id uint16, filters []string) packet {
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, filter := range filters {
+		body = appendString(body, filter)
+		body = append(body, 0)
+	}
+
+	return packet{kind: packetSubscribe, flags: subscribeFlags, body: body}
+}
+
+func publishPacket(topic string, payload []byte) packet {
+	return packet{kind: packetPublish, body: append(appendString(nil, topic), payload...)}
+}
+
+// parsePublish returns the message in a PUBLISH along with its packet id,
+// 0 for QoS 0 messages which get no acknowledgement.
+func parsePublish(p packet) (Message, uint16, error) {
+	topic, rest, err := readString(p.body)
+	if err != nil {
+		return Message{}, 0, err
+	}
+
+	var id uint16
+	if qos := (p.flags >> 1) & 0x03; qos > 0 {
+		if len(rest) < 2 {
+			return Message{}, 0, ErrMalformed
+		}
+
+		id = binary.BigEndian.Uint16(rest)
+		rest = rest[2:]
+	}
+
+	return Message{Topic: topic, Payload: rest, Retained: p.flags&0x01 != 0}, id, nil
+}
+
+func pubackPacket(id uint16) packet {
+	return packet{kind: packetPuback, body: binary.BigEndian.AppendUint16(nil, id)}
+}
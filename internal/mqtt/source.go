@@ -0,0 +1,106 @@
+package mqtt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// placeholder shows until the first message comes in.
+	placeholder = "--"
+)
+
+var (
+	ErrNoField = errors.New("mqtt: error, payload has no such field")
+)
+
+// Value is a line source showing the latest payload of a topic after a
+// label, or one field of it when the payload is JSON.
+type Value struct {
+	label string
+	field string
+
+	mu   sync.Mutex
+	text string
+}
+
+// NewValue shows field of the payload, the whole payload when field is
+// empty. Fields are dot separated paths like `sensors.0.temp`.
+func NewValue(label, field string) *Value {
+	return &Value{label: label, field: field, text: placeholder}
+}
+
+// Update is the Handler that feeds v.
+func (v *Value) Update(msg Message) {
+	text, err := Extract(msg.Payload, v.field)
+	if err != nil {
+		text = "?"
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.text = text
+}
+
+func (v *Value) String() string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	return v.label + v.text
+}
+
+// Extract returns payload as text, or field of it when field is set.
+// Numbers are kept as the publisher wrote them.
+func Extract(payload []byte, field string) (string, error) {
+	if field == "" {
+		return strings.Join(strings.Fields(string(payload)), " "), nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+
+	for _, key := range strings.Split(field, ".") {
+		switch node := value.(type) {
+		case map[string]any:
+			child, ok := node[key]
+			if !ok {
+				return "", fmt.Errorf("%w: %s", ErrNoField, field)
+			}
+
+			value = child
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return "", fmt.Errorf("%w: %s", ErrNoField, field)
+			}
+
+			value = node[idx]
+		default:
+			return "", fmt.Errorf("%w: %s", ErrNoField, field)
+		}
+	}
+
+	switch node := value.(type) {
+	case string:
+		return node, nil
+	case json.Number:
+		return node.String(), nil
+	case nil:
+		return "", nil
+	}
+
+	text, err := json.Marshal(value)
+
+	return string(text), err
+}
@@ -0,0 +1,92 @@
+package notify
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type Notification struct {
+	Text      string
+	ExpiresAt time.Time
+}
+
+// Overlay queues notifications on top of the page on display. The oldest
+// one shows until it is dismissed or expires.
+type Overlay struct {
+	mu    sync.Mutex
+	queue []Notification
+}
+
+func NewOverlay() *Overlay {
+	return &Overlay{}
+}
+
+// Post queues text, a ttl of 0 keeps it until dismissed.
+func (o *Overlay) Post(text string, ttl time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	notification := Notification{Text: text}
+	if ttl > 0 {
+		notification.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	o.queue = append(o.queue, notification)
+}
+
+// Dismiss drops the notification on display, it reports false when there
+// was none.
+func (o *Overlay) Dismiss() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now())
+
+	if len(o.queue) == 0 {
+		return false
+	}
+
+	o.queue = o.queue[1:]
+
+	return true
+}
+
+func (o *Overlay) Pending() []Notification {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now())
+
+	return append([]Notification{}, o.queue...)
+}
+
+// Current returns the notification to show, with a counter in front when
+// more are waiting.
+func (o *Overlay) Current() (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.expire(time.Now())
+
+	switch len(o.queue) {
+	case 0:
+		return "", false
+	case 1:
+		return o.queue[0].Text, true
+	}
+
+	return fmt.Sprintf("[1/%d] %s", len(o.queue), o.queue[0].Text), true
+}
+
+func (o *Overlay) expire(now time.Time) {
+	kept := o.queue[:0]
+
+	for _, notification := range o.queue {
+		if notification.ExpiresAt.IsZero() || now.Before(notification.ExpiresAt) {
+			kept = append(kept, notification)
+		}
+	}
+
+	o.queue = kept
+}
@@ -0,0 +1,34 @@
+package notify
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverlay(t *testing.T) {
+	overlay := NewOverlay()
+
+	if _, ok := overlay.Current(); ok {
+		t.Fatal("empty overlay shows a notification")
+	}
+
+	overlay.Post("deploy done", 0)
+	overlay.Post("backup failed", 0)
+	overlay.Post("gone soon", time.Nanosecond)
+
+	time.Sleep(time.Millisecond)
+
+	if text, _ := overlay.Current(); text != "[1/2] deploy done" {
+		t.Errorf("Current = %q", text)
+	}
+
+	overlay.Dismiss()
+
+	if text, _ := overlay.Current(); text != "backup failed" {
+		t.Errorf("Current after dismiss = %q", text)
+	}
+
+	if !overlay.Dismiss() || overlay.Dismiss() {
+		t.Error("Dismiss should only succeed while something is on display")
+	}
+}
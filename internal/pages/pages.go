@@ -0,0 +1,202 @@
+package pages
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+var (
+	ErrUnknownPage = errors.New("pages: error, no page with that name")
+	ErrUnknownLine = errors.New("pages: error, no such line, pages have lines 1 to 4")
+)
+
+// Page is a named layout, one line source per display line. Lines left
+// nil show up blank.
+type Page struct {
+	Name  string
+	Lines [4]fmt.Stringer
+
+	mu     sync.Mutex
+	pinned map[int]Text
+	label  string
+}
+
+// Text is a fixed line.
+type Text string
+
+func (t Text) String() string {
+	return string(t)
+}
+
+// Line returns the source for line idx, blank when the page leaves it out.
+func (p *Page) Line(idx int) fmt.Stringer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if text, ok := p.pinned[idx]; ok {
+		return text
+	}
+
+	if p.Lines[idx] == nil {
+		return Text("")
+	}
+
+	return p.Lines[idx]
+}
+
+// Label is what the page is called on the display, its name unless
+// SetLabel gave it another.
+func (p *Page) Label() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.label == "" {
+		return p.Name
+	}
+
+	return p.label
+}
+
+func (p *Page) SetLabel(label string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.label = label
+}
+
+// Pin shows text on line idx in place of its source until Unpin.
+func (p *Page) Pin(idx int, text string) error {
+	if idx < 0 || idx >= len(p.Lines) {
+		return fmt.Errorf("%w: %d", ErrUnknownLine, idx+1)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.pinned == nil {
+		p.pinned = map[int]Text{}
+	}
+
+	p.pinned[idx] = Text(text)
+
+	return nil
+}
+
+func (p *Page) Unpin(idx int) error {
+	if idx < 0 || idx >= len(p.Lines) {
+		return fmt.Errorf("%w: %d", ErrUnknownLine, idx+1)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.pinned, idx)
+
+	return nil
+}
+
+// Pager keeps the pages in order and which one is on display.
+type Pager struct {
+	mu     sync.Mutex
+	pages  []*Page
+	active int
+}
+
+// NewPager keeps its own copy of pages, so pagers made from the same list
+// add and remove pages without touching each other.
+func NewPager(pages ...*Page) *Pager {
+	return &Pager{pages: slices.Clone(pages)}
+}
+
+func (p *Pager) Active() *Page {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.pages[p.active]
+}
+
+// Find returns the page called name.
+func (p *Pager) Find(name string) (*Page, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, page := range p.pages {
+		if page.Name == name {
+			return page, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownPage, name)
+}
+
+func (p *Pager) Names() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, len(p.pages))
+	for idx, page := range p.pages {
+		names[idx] = page.Name
+	}
+
+	return names
+}
+
+// Move goes delta pages forward, or backward when negative, wrapping
+// around at both ends.
+func (p *Pager) Move(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	count := len(p.pages)
+	p.active = ((p.active+delta)%count + count) % count
+}
+
+func (p *Pager) Switch(name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for idx, page := range p.pages {
+		if page.Name == name {
+			p.active = idx
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s", ErrUnknownPage, name)
+}
+
+// Add puts page at the end, or replaces the page that has the same name.
+func (p *Pager) Add(page *Page) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for idx := range p.pages {
+		if p.pages[idx].Name == page.Name {
+			p.pages[idx] = page
+			return
+		}
+	}
+
+	p.pages = append(p.pages, page)
+}
+
+// Remove drops the page called name, the last page left stays.
+func (p *Pager) Remove(name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for idx := range p.pages {
+		if p.pages[idx].Name != name || len(p.pages) == 1 {
+			continue
+		}
+
+		p.pages = append(p.pages[:idx], p.pages[idx+1:]...)
+		if p.active > idx || p.active == len(p.pages) {
+			p.active--
+		}
+
+		return
+	}
+}
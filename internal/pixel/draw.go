@@ -0,0 +1,98 @@
+package pixel
+
+import (
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/lcdimage"
+)
+
+const (
+	glyphCols = 5
+	glyphRows = 8
+
+	// CellWidth and CellHeight are the size of one character at text
+	// size 1, glyphs get a column of spacing.
+	CellWidth  = glyphCols + 1
+	CellHeight = glyphRows
+
+	IconSize = 8
+)
+
+// font is the A00 character ROM, the text goes through the same
+// character map as on text displays.
+var font, _ = lcdimage.ROM("A00")
+
+// DrawText draws text with its top left corner at x, y, every dot of the
+// glyphs as a size by size square. Text past maxX is cut off. It returns
+// where the text ends.
+func DrawText(fb *Framebuffer, x, y int, text string, size, maxX int) int {
+	size = max(size, 1)
+
+	for _, code := range display.ReplaceRuneWithLCDCharMap(text) {
+		if x+glyphCols*size > maxX {
+			break
+		}
+
+		// Custom characters only exist on the character displays.
+		if code >= 0x08 {
+			drawBits(fb, x, y, font[code][:], glyphCols, size)
+		}
+
+		x += CellWidth * size
+	}
+
+	return x
+}
+
+// DrawIcon draws the named icon at x, y scaled by size, unknown icons
+// are left out.
+func DrawIcon(fb *Framebuffer, x, y int, name string, size int) bool {
+	icon, ok := icons[name]
+	if !ok {
+		return false
+	}
+
+	drawBits(fb, x, y, icon[:], IconSize, max(size, 1))
+
+	return true
+}
+
+// DrawGraph draws samples from 0 to 100 as bars in the w by h box at x, y,
+// one pixel wide each with the latest sample on the right.
+func DrawGraph(fb *Framebuffer, x, y, w, h int, samples []float64) {
+	if len(samples) > w {
+		samples = samples[len(samples)-w:]
+	}
+
+	left := x + w - len(samples)
+
+	for idx, sample := range samples {
+		height := int(min(max(sample, 0), 100) * float64(h) / 100)
+
+		for dy := range height {
+			fb.Set(left+idx, y+h-1-dy, true)
+		}
+	}
+
+	// A baseline shows where the graph is while it is still empty.
+	for dx := range w {
+		fb.Set(x+dx, y+h-1, true)
+	}
+}
+
+// drawBits draws rows of a bitmap width dots wide, the leftmost dot being
+// the highest of those bits.
+func drawBits(fb *Framebuffer, x, y int, rows []byte, width, size int) {
+	for row, bits := range rows {
+		for col := range width {
+			if bits&(1<<(width-1-col)) == 0 {
+				continue
+			}
+
+			for dy := range size {
+				for dx := range size {
+					fb.Set(x+col*size+dx, y+row*size+dy, true)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package pixel
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+const (
+	SSD1306Width  = 128
+	SSD1306Height = 64
+
+	// PageHeight is how many pixel rows one framebuffer byte covers.
+	PageHeight = 8
+
+	// DefaultChunk keeps each fb: command small enough for the serial
+	// buffer of an Arduino.
+	DefaultChunk = 32
+)
+
+var (
+	Off = color.Gray{Y: 0x00}
+	On  = color.Gray{Y: 0xff}
+)
+
+// Framebuffer is a 1-bit image laid out like SSD1306 memory: pages of 8
+// pixel rows, one byte per column with the top pixel in bit 0.
+type Framebuffer struct {
+	Width, Height int
+	buf           []byte
+}
+
+func NewFramebuffer(width, height int) *Framebuffer {
+	return &Framebuffer{
+		Width:  width,
+		Height: height,
+		buf:    make([]byte, width*pages(height)),
+	}
+}
+
+func pages(height int) int {
+	return (height + PageHeight - 1) / PageHeight
+}
+
+func (fb *Framebuffer) Pages() int {
+	return pages(fb.Height)
+}
+
+// Set lights the pixel at x, y when on, pixels outside are ignored.
+func (fb *Framebuffer) Set(x, y int, on bool) {
+	if x < 0 || y < 0 || x >= fb.Width || y >= fb.Height {
+		return
+	}
+
+	idx := (y/PageHeight)*fb.Width + x
+	bit := byte(1) << (y % PageHeight)
+
+	if on {
+		fb.buf[idx] |= bit
+	} else {
+		fb.buf[idx] &^= bit
+	}
+}
+
+func (fb *Framebuffer) At(x, y int) bool {
+	if x < 0 || y < 0 || x >= fb.Width || y >= fb.Height {
+		return false
+	}
+
+	return fb.buf[(y/PageHeight)*fb.Width+x]&(1<<(y%PageHeight)) != 0
+}
+
+func (fb *Framebuffer) Clear() {
+	clear(fb.buf)
+}
+
+// Page returns the bytes of page p, one per column.
+func (fb *Framebuffer) Page(p int) []byte {
+	return fb.buf[p*fb.Width : (p+1)*fb.Width]
+}
+
+// Chunk is a run of bytes within one page, starting at column Col.
+type Chunk struct {
+	Page, Col int
+	Data      []byte
+}
+
+// Chunks cuts the framebuffer into runs of size bytes, only those that
+// differ from prev when prev is given.
+func (fb *Framebuffer) Chunks(prev *Framebuffer, size int) []Chunk {
+	chunks := []Chunk{}
+
+	for p := range fb.Pages() {
+		page := fb.Page(p)
+
+		for col := 0; col < fb.Width; col += size {
+			end := min(col+size, fb.Width)
+
+			if prev != nil && prev.Width == fb.Width && prev.Height == fb.Height &&
+				bytes.Equal(prev.Page(p)[col:end], page[col:end]) {
+				continue
+			}
+
+			chunks = append(chunks, Chunk{Page: p, Col: col, Data: bytes.Clone(page[col:end])})
+		}
+	}
+
+	return chunks
+}
+
+// Image draws the framebuffer with every pixel as a scale by scale square.
+func (fb *Framebuffer) Image(scale int) *image.Paletted {
+	scale = max(scale, 1)
+
+	img := image.NewPaletted(image.Rect(0, 0, fb.Width*scale, fb.Height*scale), color.Palette{Off, On})
+
+	for y := range fb.Height {
+		for x := range fb.Width {
+			if !fb.At(x, y) {
+				continue
+			}
+
+			for dy := range scale {
+				for dx := range scale {
+					img.SetColorIndex(x*scale+dx, y*scale+dy, 1)
+				}
+			}
+		}
+	}
+
+	return img
+}
+
+func (fb *Framebuffer) WritePNG(w io.Writer, scale int) error {
+	return png.Encode(w, fb.Image(scale))
+}
@@ -0,0 +1,24 @@
+package pixel
+
+const (
+	IconClock   = "clock"
+	IconSun     = "sun"
+	IconCloud   = "cloud"
+	IconRain    = "rain"
+	IconSnow    = "snow"
+	IconCPU     = "cpu"
+	IconMemory  = "memory"
+	IconNetwork = "network"
+)
+
+// icons are 8x8, one byte per row with bit 7 as the leftmost pixel.
+var icons = map[string][IconSize]byte{
+	IconClock:   {0x3c, 0x42, 0x91, 0x91, 0x9d, 0x81, 0x42, 0x3c},
+	IconSun:     {0x10, 0x54, 0x38, 0xee, 0x38, 0x54, 0x10, 0x00},
+	IconCloud:   {0x00, 0x30, 0x4c, 0x42, 0x81, 0x81, 0x7e, 0x00},
+	IconRain:    {0x30, 0x4c, 0x82, 0x7e, 0x00, 0x49, 0x92, 0x00},
+	IconSnow:    {0x10, 0x92, 0x54, 0x38, 0x54, 0x92, 0x10, 0x00},
+	IconCPU:     {0x24, 0x7e, 0xc3, 0x5a, 0x5a, 0xc3, 0x7e, 0x24},
+	IconMemory:  {0x00, 0xff, 0x81, 0xb5, 0x81, 0xff, 0xaa, 0x00},
+	IconNetwork: {0x20, 0x70, 0xa8, 0x24, 0x24, 0x15, 0x0e, 0x04},
+}
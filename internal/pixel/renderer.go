@@ -0,0 +1,127 @@
+package pixel
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	iconGap = 2
+)
+
+var (
+	ErrInvalidSizes = errors.New("pixel: error parsing text sizes, please specify one size from 1 to 4 per line (e.g. 2,1,1,1)")
+)
+
+// Iconed line sources get an icon drawn in front of their text.
+type Iconed interface {
+	Icon() string
+}
+
+// Graphed line sources get their recent samples, 0 to 100, drawn as a
+// graph after their text.
+type Graphed interface {
+	Samples() []float64
+}
+
+// Line adds an icon and a graph to a line source for pixel displays,
+// text displays only see its text.
+type Line struct {
+	fmt.Stringer
+	icon    string
+	samples func() []float64
+}
+
+// NewLine decorates text, samples may be nil for no graph.
+func NewLine(text fmt.Stringer, icon string, samples func() []float64) *Line {
+	return &Line{Stringer: text, icon: icon, samples: samples}
+}
+
+func (l *Line) Icon() string {
+	return l.icon
+}
+
+func (l *Line) Samples() []float64 {
+	if l.samples == nil {
+		return nil
+	}
+
+	return l.samples()
+}
+
+// Renderer lays page lines out on a framebuffer, top to bottom with the
+// space left spread between them.
+type Renderer struct {
+	Width, Height int
+	// Sizes holds the text size of every line, 1 is 8 pixels tall.
+	Sizes []int
+}
+
+func NewRenderer(width, height int, sizes []int) *Renderer {
+	return &Renderer{Width: width, Height: height, Sizes: sizes}
+}
+
+// ParseSizes reads text sizes like `2,1,1,1`.
+func ParseSizes(spec string) ([]int, error) {
+	sizes := []int{}
+
+	for _, field := range strings.Split(spec, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || size < 1 || size > 4 {
+			return nil, ErrInvalidSizes
+		}
+
+		sizes = append(sizes, size)
+	}
+
+	return sizes, nil
+}
+
+func (r *Renderer) Render(lines []fmt.Stringer) *Framebuffer {
+	fb := NewFramebuffer(r.Width, r.Height)
+
+	count := min(len(lines), len(r.Sizes))
+	used := 0
+	for _, size := range r.Sizes[:count] {
+		used += CellHeight * size
+	}
+
+	gap := 0
+	if count > 1 && used < r.Height {
+		gap = (r.Height - used) / (count - 1)
+	}
+
+	y := 0
+	for idx, line := range lines[:count] {
+		size := r.Sizes[idx]
+		if line != nil {
+			r.drawLine(fb, y, size, line)
+		}
+
+		y += CellHeight*size + gap
+	}
+
+	return fb
+}
+
+func (r *Renderer) drawLine(fb *Framebuffer, y, size int, line fmt.Stringer) {
+	x := 0
+	maxX := r.Width
+
+	if iconed, ok := line.(Iconed); ok && DrawIcon(fb, x, y, iconed.Icon(), size) {
+		x += IconSize*size + iconGap
+	}
+
+	if graphed, ok := line.(Graphed); ok {
+		if samples := graphed.Samples(); samples != nil {
+			width := r.Width / 3
+			maxX -= width + iconGap
+
+			DrawGraph(fb, r.Width-width, y, width, CellHeight*size, samples)
+		}
+	}
+
+	DrawText(fb, x, y, strings.TrimSpace(line.String()), size, maxX)
+}
@@ -0,0 +1,95 @@
+package pixel
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "Rewrite golden files in testdata with the current output.")
+
+type text string
+
+func (t text) String() string {
+	return string(t)
+}
+
+func TestFramebufferLayout(t *testing.T) {
+	fb := NewFramebuffer(SSD1306Width, SSD1306Height)
+
+	fb.Set(3, 0, true)
+	fb.Set(3, 9, true)
+	fb.Set(200, 9, true)
+
+	if fb.Page(0)[3] != 0x01 || fb.Page(1)[3] != 0x02 {
+		t.Errorf("pages = %x, %x", fb.Page(0)[3], fb.Page(1)[3])
+	}
+
+	if !fb.At(3, 9) || fb.At(4, 9) {
+		t.Error("At does not match Set")
+	}
+
+	if chunks := fb.Chunks(nil, DefaultChunk); len(chunks) != 8*SSD1306Width/DefaultChunk {
+		t.Errorf("full redraw takes %d chunks", len(chunks))
+	}
+
+	prev := NewFramebuffer(SSD1306Width, SSD1306Height)
+	prev.Set(3, 0, true)
+
+	chunks := fb.Chunks(prev, DefaultChunk)
+	if len(chunks) != 1 || chunks[0].Page != 1 || chunks[0].Col != 0 || chunks[0].Data[3] != 0x02 {
+		t.Errorf("Chunks = %+v", chunks)
+	}
+}
+
+func TestRender(t *testing.T) {
+	history := []float64{}
+	for idx := range 60 {
+		history = append(history, float64(idx%20)*5)
+	}
+
+	renderer := NewRenderer(SSD1306Width, SSD1306Height, []int{2, 1, 1, 1})
+	fb := renderer.Render([]fmt.Stringer{
+		text("12:30:05"),
+		NewLine(text("cpu 42%"), IconCPU, func() []float64 { return history }),
+		NewLine(text("mem 3.1G/15G"), IconMemory, nil),
+		NewLine(text("up 2d 4h"), IconClock, nil),
+	})
+
+	var out bytes.Buffer
+	if err := fb.WritePNG(&out, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	checkGolden(t, "system.png", out.Bytes())
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("reading golden file, run with -update to create it: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("image differs from %s, run with -update if this is intended", path)
+	}
+}
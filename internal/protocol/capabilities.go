@@ -0,0 +1,269 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ProtocolVersion = 2
+
+	CmdHello = "hello:"
+	CmdCaps  = "caps:"
+)
+
+var (
+	ErrInvalidCapabilities = errors.New("protocol: error parsing device capabilities")
+)
+
+// Capabilities is what the device says about itself in reply to hello.
+type Capabilities struct {
+	Version   int
+	Cols      int
+	Rows      int
+	ROM       string
+	CGRAM     int
+	Backlight bool
+	Buttons   int
+	Framed    bool
+
+	// MaxRate is the most frames per second the device keeps up with.
+	MaxRate int
+}
+
+// LegacyCapabilities describes firmware that predates the handshake.
+var LegacyCapabilities = Capabilities{
+	Version: 1,
+	Cols:    20,
+	Rows:    4,
+	ROM:     "A00",
+	MaxRate: 10,
+}
+
+func HelloCommand() []byte {
+	return fmt.Appendf(nil, "%s%d\n", CmdHello, ProtocolVersion)
+}
+
+// CapsReply is how the device answers hello, a single token like
+//
+//	caps:v=2,cols=20,rows=4,rom=A00,cgram=8,bl=1,btn=2,rate=20,framed=1
+func CapsReply(caps Capabilities) []byte {
+	return fmt.Appendf(nil, "%sv=%d,cols=%d,rows=%d,rom=%s,cgram=%d,bl=%d,btn=%d,rate=%d,framed=%d\n",
+		CmdCaps, caps.Version, caps.Cols, caps.Rows, caps.ROM, caps.CGRAM, flag(caps.Backlight), caps.Buttons, caps.MaxRate, flag(caps.Framed))
+}
+
+func flag(value bool) int {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// ParseCapabilities reads a caps token, keys the device leaves out keep
+// their legacy value and unknown keys are skipped for newer firmware.
+func ParseCapabilities(token string) (Capabilities, error) {
+	caps := LegacyCapabilities
+
+	fields, ok := strings.CutPrefix(token, CmdCaps)
+	if !ok {
+		return caps, ErrInvalidCapabilities
+	}
+
+	for _, field := range strings.Split(fields, ",") {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return caps, ErrInvalidCapabilities
+		}
+
+		if key == "rom" {
+			caps.ROM = value
+			continue
+		}
+
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return caps, fmt.Errorf("%w: %s", ErrInvalidCapabilities, field)
+		}
+
+		switch key {
+		case "v":
+			caps.Version = number
+		case "cols":
+			caps.Cols = number
+		case "rows":
+			caps.Rows = number
+		case "cgram":
+			caps.CGRAM = number
+		case "bl":
+			caps.Backlight = number != 0
+		case "btn":
+			caps.Buttons = number
+		case "rate":
+			caps.MaxRate = number
+		case "framed":
+			caps.Framed = number != 0
+		}
+	}
+
+	if caps.Cols <= 0 || caps.Rows <= 0 || caps.MaxRate <= 0 {
+		return caps, ErrInvalidCapabilities
+	}
+
+	return caps, nil
+}
+
+// Handshake greets the device once it prompts and reads back its
+// capabilities. Firmware that predates the handshake ignores hello and
+// either prompts again or says nothing until the next command, both fall
+// back to LegacyCapabilities, the latter once timeout passes. Either way the
+// device is left waiting for the next command.
+//
+// The returned connection replaces rwc from then on, it still holds the
+// read that was waiting when timeout passed. A timeout of 0 waits forever.
+func Handshake(rwc io.ReadWriteCloser, timeout time.Duration) (Capabilities, io.ReadWriteCloser, error) {
+	if err := WaitPrompt(rwc); err != nil {
+		return LegacyCapabilities, rwc, err
+	}
+
+	if _, err := rwc.Write(HelloCommand()); err != nil {
+		return LegacyCapabilities, rwc, err
+	}
+
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		expired = timer.C
+	}
+
+	var (
+		caps  = LegacyCapabilities
+		token []byte
+		reads = make(chan byteRead, 1)
+	)
+
+	for {
+		go readByte(rwc, reads)
+
+		select {
+		case <-expired:
+			return caps, &lateConn{ReadWriteCloser: rwc, late: reads}, nil
+		case read := <-reads:
+			if read.n == 0 {
+				if read.err != nil {
+					return LegacyCapabilities, rwc, read.err
+				}
+
+				continue
+			}
+
+			switch read.b {
+			case ' ', '\t', '\r', '\n':
+			default:
+				token = append(token, read.b)
+				continue
+			}
+
+			switch {
+			case string(token) == CmdPrompt:
+				return caps, rwc, nil
+			case bytes.HasPrefix(token, []byte(CmdCaps)):
+				var err error
+
+				caps, err = ParseCapabilities(string(token))
+				if err != nil {
+					return LegacyCapabilities, rwc, err
+				}
+			}
+
+			token = token[:0]
+		}
+	}
+}
+
+type byteRead struct {
+	b   byte
+	n   int
+	err error
+}
+
+func readByte(r io.Reader, reads chan<- byteRead) {
+	b := make([]byte, 1)
+	n, err := r.Read(b)
+
+	reads <- byteRead{b: b[0], n: n, err: err}
+}
+
+// lateConn hands out what the read left waiting by Handshake brings in
+// before reading on.
+type lateConn struct {
+	io.ReadWriteCloser
+
+	late <-chan byteRead
+}
+
+func (lc *lateConn) Read(p []byte) (int, error) {
+	if lc.late == nil || len(p) == 0 {
+		return lc.ReadWriteCloser.Read(p)
+	}
+
+	read := <-lc.late
+	lc.late = nil
+
+	p[0] = read.b
+
+	return read.n, read.err
+}
+
+func WaitPrompt(r io.Reader) error {
+	for {
+		token, err := ReadToken(r)
+		if err != nil {
+			return err
+		}
+
+		if token == CmdPrompt {
+			return nil
+		}
+	}
+}
+
+// ReadToken reads one whitespace separated token a byte at a time, so
+// nothing past the token is taken from r.
+func ReadToken(r io.Reader) (string, error) {
+	var (
+		token []byte
+		b     = make([]byte, 1)
+	)
+
+	for {
+		n, err := r.Read(b)
+		if err != nil {
+			if len(token) > 0 && errors.Is(err, io.EOF) {
+				return string(token), nil
+			}
+
+			return "", err
+		}
+
+		if n == 0 {
+			continue
+		}
+
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, b[0])
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package protocol_test
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/lcdsim"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+type blankRenderer struct{}
+
+func (blankRenderer) Render([]byte) error    { return nil }
+func (blankRenderer) Clear() error           { return nil }
+func (blankRenderer) SetBacklight(int) error { return nil }
+
+func TestParseCapabilities(t *testing.T) {
+	caps, err := protocol.ParseCapabilities("caps:v=2,cols=16,rows=2,rom=A02,cgram=8,bl=1,btn=3,rate=25,framed=1,future=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := protocol.Capabilities{
+		Version:   2,
+		Cols:      16,
+		Rows:      2,
+		ROM:       "A02",
+		CGRAM:     8,
+		Backlight: true,
+		Buttons:   3,
+		MaxRate:   25,
+		Framed:    true,
+	}
+	if caps != want {
+		t.Errorf("ParseCapabilities = %+v, want %+v", caps, want)
+	}
+
+	back, err := protocol.ParseCapabilities(string(protocol.CapsReply(want)[:len(protocol.CapsReply(want))-1]))
+	if err != nil || back != want {
+		t.Errorf("CapsReply does not round trip: %+v, %v", back, err)
+	}
+
+	for _, token := range []string{"hello:2", "caps:cols=x", "caps:cols=0", "caps:rows"} {
+		if _, err := protocol.ParseCapabilities(token); err == nil {
+			t.Errorf("ParseCapabilities(%q) should fail", token)
+		}
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	dev := lcdsim.NewDevice(16, 2, time.Millisecond, blankRenderer{})
+	defer dev.Close()
+
+	caps, _, err := protocol.Handshake(dev, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps.Version != protocol.ProtocolVersion || caps.Cols != 16 || caps.Rows != 2 {
+		t.Errorf("Handshake = %+v, want version 2 and 16x2", caps)
+	}
+}
+
+func TestHandshakeLegacy(t *testing.T) {
+	dev := lcdsim.NewDevice(20, 4, time.Millisecond, blankRenderer{})
+	dev.SetCapabilities(protocol.LegacyCapabilities)
+	defer dev.Close()
+
+	caps, _, err := protocol.Handshake(dev, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps != protocol.LegacyCapabilities {
+		t.Errorf("Handshake = %+v, want legacy capabilities", caps)
+	}
+}
+
+func TestHandshakeSilentLegacy(t *testing.T) {
+	host, dev := net.Pipe()
+	defer host.Close()
+	defer dev.Close()
+
+	// The firmware prompts, takes hello without a word and only prompts
+	// again once the next command is done.
+	go func() {
+		in := bufio.NewReader(dev)
+
+		for {
+			if _, err := dev.Write([]byte(protocol.CmdPrompt + "\n")); err != nil {
+				return
+			}
+
+			if _, err := in.ReadString('\n'); err != nil {
+				return
+			}
+
+			if _, err := in.ReadString('\n'); err != nil {
+				return
+			}
+		}
+	}()
+
+	caps, conn, err := protocol.Handshake(host, 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps != protocol.LegacyCapabilities {
+		t.Errorf("Handshake = %+v, want legacy capabilities", caps)
+	}
+
+	if _, err := conn.Write(protocol.ClearCommand()); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := protocol.ReadToken(conn)
+	if err != nil || token != protocol.CmdPrompt {
+		t.Errorf("after the handshake read %q, %v, want the next prompt", token, err)
+	}
+}
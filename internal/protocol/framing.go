@@ -0,0 +1,261 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// In framed mode every host command travels as
+//
+//	STX | seq | length (2 bytes, big endian) | payload | CRC-16 (2 bytes) | ETX
+//
+// where length counts the payload before escaping and the CRC is
+// CRC-16/CCITT-FALSE over seq, length and payload. STX, ETX and DLE inside
+// the frame are sent as DLE followed by the byte xor 0x20. The device
+// answers each frame with an `ack:<seq>` or `nak:<seq>` token, then prompts
+// as usual.
+
+const (
+	frameStart  = 0x02
+	frameEnd    = 0x03
+	frameEscape = 0x10
+
+	CmdFramed = "framed:"
+	TokenAck  = "ack:"
+	TokenNak  = "nak:"
+
+	DefaultAckTimeout = 500 * time.Millisecond
+	DefaultRetries    = 3
+)
+
+var (
+	ErrBadFrame = errors.New("protocol: error, malformed frame")
+	ErrBadCRC   = errors.New("protocol: error, frame checksum mismatch")
+	ErrNoAck    = errors.New("protocol: error, device did not acknowledge frame")
+)
+
+// FramedCommand asks the device to switch to framed mode.
+func FramedCommand() []byte {
+	return []byte(CmdFramed + "1\n")
+}
+
+// AckReply is how the device answers a frame.
+func AckReply(seq byte, ok bool) []byte {
+	if ok {
+		return fmt.Appendf(nil, "%s%d\n", TokenAck, seq)
+	}
+
+	return fmt.Appendf(nil, "%s%d\n", TokenNak, seq)
+}
+
+func crc16(data []byte) uint16 {
+	crc := uint16(0xffff)
+
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+
+	return crc
+}
+
+func EncodeFrame(seq byte, payload []byte) []byte {
+	body := []byte{seq}
+	body = binary.BigEndian.AppendUint16(body, uint16(len(payload)))
+	body = append(body, payload...)
+	body = binary.BigEndian.AppendUint16(body, crc16(body))
+
+	frame := []byte{frameStart}
+	for _, b := range body {
+		if b == frameStart || b == frameEnd || b == frameEscape {
+			frame = append(frame, frameEscape, b^0x20)
+			continue
+		}
+
+		frame = append(frame, b)
+	}
+
+	return append(frame, frameEnd)
+}
+
+// NextFrame finds the first complete frame in buf. It returns the frame
+// with STX and ETX stripped, nil while there is no complete frame yet, and
+// how many bytes of buf can be dropped. Bytes before STX are noise.
+func NextFrame(buf []byte) ([]byte, int) {
+	start := bytes.IndexByte(buf, frameStart)
+	if start < 0 {
+		return nil, len(buf)
+	}
+
+	end := bytes.IndexByte(buf[start+1:], frameEnd)
+	if end < 0 {
+		return nil, start
+	}
+
+	return buf[start+1 : start+1+end], start + end + 2
+}
+
+// DecodeFrame takes a frame as returned by NextFrame. The sequence number
+// is returned whenever it could be read, so a bad frame can still be NAKed.
+func DecodeFrame(frame []byte) (byte, []byte, error) {
+	body := make([]byte, 0, len(frame))
+
+	for idx := 0; idx < len(frame); idx++ {
+		if frame[idx] == frameEscape {
+			idx++
+			if idx == len(frame) {
+				return 0, nil, ErrBadFrame
+			}
+
+			body = append(body, frame[idx]^0x20)
+			continue
+		}
+
+		body = append(body, frame[idx])
+	}
+
+	if len(body) < 5 {
+		return 0, nil, ErrBadFrame
+	}
+
+	seq := body[0]
+	length := int(binary.BigEndian.Uint16(body[1:3]))
+	if len(body) != 3+length+2 {
+		return seq, nil, ErrBadFrame
+	}
+
+	if crc16(body[:3+length]) != binary.BigEndian.Uint16(body[3+length:]) {
+		return seq, nil, ErrBadCRC
+	}
+
+	return seq, body[3 : 3+length], nil
+}
+
+type ackToken struct {
+	seq byte
+	ok  bool
+}
+
+// FramedConn sends every Write as a frame and waits for the device to
+// acknowledge it, retransmitting on NAK or timeout. Acknowledgements are
+// taken out of what Read returns, everything else is kept for Read so the
+// device can talk while a Write waits.
+type FramedConn struct {
+	conn   io.ReadWriteCloser
+	tokens *tokenSplitter
+
+	AckTimeout time.Duration
+	Retries    int
+
+	seq  byte
+	acks chan ackToken
+}
+
+func NewFramedConn(conn io.ReadWriteCloser) *FramedConn {
+	fc := &FramedConn{
+		conn:       conn,
+		AckTimeout: DefaultAckTimeout,
+		Retries:    DefaultRetries,
+		acks:       make(chan ackToken, 8),
+	}
+	fc.tokens = newTokenSplitter(conn, fc.takeAck)
+
+	return fc
+}
+
+func (fc *FramedConn) takeAck(token string) bool {
+	ack, ok := parseAck(token)
+	if !ok {
+		return false
+	}
+
+	select {
+	case fc.acks <- ack:
+	default:
+	}
+
+	return true
+}
+
+func parseAck(token string) (ackToken, bool) {
+	ok := true
+
+	value, found := strings.CutPrefix(token, TokenAck)
+	if !found {
+		value, found = strings.CutPrefix(token, TokenNak)
+		ok = false
+	}
+
+	if !found {
+		return ackToken{}, false
+	}
+
+	seq, err := strconv.Atoi(value)
+	if err != nil || seq < 0 || seq > 255 {
+		return ackToken{}, false
+	}
+
+	return ackToken{seq: byte(seq), ok: ok}, true
+}
+
+func (fc *FramedConn) Read(p []byte) (int, error) {
+	return fc.tokens.Read(p)
+}
+
+// Write takes exactly one command, its trailing newline is left out of the
+// frame since the length already delimits it.
+func (fc *FramedConn) Write(p []byte) (int, error) {
+	fc.seq++
+	frame := EncodeFrame(fc.seq, bytes.TrimSuffix(p, []byte("\n")))
+
+	for range fc.Retries + 1 {
+		if _, err := fc.conn.Write(frame); err != nil {
+			return 0, err
+		}
+
+		acked, err := fc.waitAck(fc.seq)
+		if err != nil {
+			return 0, err
+		}
+
+		if acked {
+			return len(p), nil
+		}
+	}
+
+	return 0, fmt.Errorf("%w: seq %d", ErrNoAck, fc.seq)
+}
+
+func (fc *FramedConn) waitAck(seq byte) (bool, error) {
+	timeout := time.After(fc.AckTimeout)
+
+	for {
+		select {
+		case ack := <-fc.acks:
+			// Stale answers to an earlier try are skipped.
+			if ack.seq == seq {
+				return ack.ok, nil
+			}
+		case <-timeout:
+			return false, nil
+		case <-fc.tokens.done:
+			return false, fc.tokens.err()
+		}
+	}
+}
+
+func (fc *FramedConn) Close() error {
+	return fc.conn.Close()
+}
@@ -0,0 +1,104 @@
+package protocol_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/lcdsim"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	payload := append([]byte("display:"), 0x00, 0x02, 0x03, 0x0a, 0x10, 'x', 0xdf)
+
+	frame := protocol.EncodeFrame(0x03, payload)
+	if bytes.IndexByte(frame[1:len(frame)-1], 0x03) >= 0 || bytes.IndexByte(frame[1:len(frame)-1], 0x02) >= 0 {
+		t.Fatalf("frame carries unescaped delimiters: %x", frame)
+	}
+
+	body, n := protocol.NextFrame(append([]byte("noise"), frame...))
+	if body == nil || n != len("noise")+len(frame) {
+		t.Fatalf("NextFrame = %x, %d", body, n)
+	}
+
+	seq, got, err := protocol.DecodeFrame(body)
+	if err != nil || seq != 0x03 || !bytes.Equal(got, payload) {
+		t.Fatalf("DecodeFrame = %d, %x, %v", seq, got, err)
+	}
+
+	body[len(body)-2] ^= 0x01
+	if _, _, err := protocol.DecodeFrame(body); !errors.Is(err, protocol.ErrBadCRC) && !errors.Is(err, protocol.ErrBadFrame) {
+		t.Errorf("DecodeFrame on a corrupted frame returned %v", err)
+	}
+}
+
+// corruptOnce flips a payload byte of the first write that goes through.
+type corruptOnce struct {
+	io.ReadWriteCloser
+	done bool
+}
+
+func (c *corruptOnce) Write(p []byte) (int, error) {
+	if !c.done && len(p) > 10 {
+		c.done = true
+		p = bytes.Clone(p)
+		p[10] ^= 0x40
+	}
+
+	return c.ReadWriteCloser.Write(p)
+}
+
+func TestFramedConnRetransmits(t *testing.T) {
+	dev := lcdsim.NewDevice(20, 4, time.Millisecond, blankRenderer{})
+	defer dev.Close()
+
+	if err := protocol.WaitPrompt(dev); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dev.Write(protocol.FramedCommand()); err != nil {
+		t.Fatal(err)
+	}
+
+	conn := protocol.NewFramedConn(&corruptOnce{ReadWriteCloser: dev})
+
+	if err := protocol.WaitPrompt(conn); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := bytes.Repeat([]byte{'\n'}, 80)
+	if _, err := conn.Write(protocol.DisplayCommand(frame)); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, row := range dev.Screen() {
+		if !bytes.Equal(row, frame[:20]) {
+			t.Fatalf("screen row is %q after retransmit", row)
+		}
+	}
+
+	if err := protocol.WaitPrompt(conn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseFramebufferCommand(t *testing.T) {
+	data := []byte{0x0a, 0xff, 0x00, '\n'}
+	buf := append(protocol.FramebufferCommand(2, 64, data), protocol.ClearCommand()...)
+
+	cmd, n := protocol.ParseCommand(buf, 80)
+	if cmd.Name != "fb:2,64,4" || !bytes.Equal(cmd.Payload, data) {
+		t.Fatalf("ParseCommand = %q, %x", cmd.Name, cmd.Payload)
+	}
+
+	if cmd, _ := protocol.ParseCommand(buf[n:], 80); cmd.Name != protocol.CmdClear {
+		t.Errorf("command after fb: = %q", cmd.Name)
+	}
+
+	if _, n := protocol.ParseCommand(buf[:n-1], 80); n != 0 {
+		t.Errorf("a partial fb: command was taken, n = %d", n)
+	}
+}
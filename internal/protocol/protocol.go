@@ -0,0 +1,125 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	CmdPrompt    = "$>:"
+	CmdDisplay   = "display:"
+	CmdClear     = "clr"
+	CmdBacklight = "backlight:"
+	CmdContrast  = "contrast:"
+
+	// CmdFramebuffer carries a run of bytes for one page of a pixel
+	// display, as fb:<page>,<col>,<len>:<bytes>. Every byte is a column
+	// of 8 pixels with the top one in bit 0, the SSD1306 layout.
+	CmdFramebuffer = "fb:"
+)
+
+type Command struct {
+	Name    string
+	Payload []byte
+}
+
+func DisplayCommand(frame []byte) []byte {
+	return slices.Concat([]byte(CmdDisplay), frame, []byte("\n"))
+}
+
+func ClearCommand() []byte {
+	return []byte(CmdClear + "\n")
+}
+
+func FramebufferCommand(page, col int, data []byte) []byte {
+	return slices.Concat(fmt.Appendf(nil, "%s%d,%d,%d:", CmdFramebuffer, page, col, len(data)), data, []byte("\n"))
+}
+
+// BacklightCommand sets the backlight brightness, 0 turns it off and 255 is full on.
+func BacklightCommand(level int) []byte {
+	return fmt.Appendf(nil, "%s%d\n", CmdBacklight, level)
+}
+
+func ContrastCommand(level int) []byte {
+	return fmt.Appendf(nil, "%s%d\n", CmdContrast, level)
+}
+
+// ParseLevel reads the level argument of a backlight or contrast command.
+func ParseLevel(cmd Command, prefix string) (int, bool) {
+	value, ok := strings.CutPrefix(cmd.Name, prefix)
+	if !ok {
+		return 0, false
+	}
+
+	level, err := strconv.Atoi(value)
+	if err != nil || level < 0 || level > 255 {
+		return 0, false
+	}
+
+	return level, true
+}
+
+// ParseCommand takes one complete host command from the start of buf and
+// returns it along with how many bytes it used, 0 means the command is not
+// complete yet. Display frames are taken by length so payload bytes are
+// never mistaken for the end of the line.
+func ParseCommand(buf []byte, frameSize int) (Command, int) {
+	if bytes.HasPrefix(buf, []byte(CmdDisplay)) {
+		cmdLen := len(CmdDisplay) + frameSize + 1
+		if len(buf) < cmdLen {
+			return Command{}, 0
+		}
+
+		return Command{
+			Name:    CmdDisplay,
+			Payload: bytes.Clone(buf[len(CmdDisplay) : cmdLen-1]),
+		}, cmdLen
+	}
+
+	if bytes.HasPrefix(buf, []byte(CmdFramebuffer)) {
+		return parseFramebuffer(buf)
+	}
+
+	idx := bytes.IndexByte(buf, '\n')
+	if idx < 0 {
+		return Command{}, 0
+	}
+
+	return Command{Name: string(buf[:idx])}, idx + 1
+}
+
+// parseFramebuffer takes a fb: command by the length in its header, the
+// header is kept as the command name. A broken header is skipped like
+// any other line.
+func parseFramebuffer(buf []byte) (Command, int) {
+	line := bytes.IndexByte(buf, '\n')
+	colon := bytes.IndexByte(buf[len(CmdFramebuffer):], ':')
+
+	size := -1
+	if colon >= 0 {
+		colon += len(CmdFramebuffer)
+		fields := strings.Split(string(buf[len(CmdFramebuffer):colon]), ",")
+
+		if n, err := strconv.Atoi(fields[len(fields)-1]); len(fields) == 3 && err == nil {
+			size = n
+		}
+	}
+
+	if size < 0 || (line >= 0 && line < colon) {
+		if line < 0 {
+			return Command{}, 0
+		}
+
+		return Command{Name: string(buf[:line])}, line + 1
+	}
+
+	cmdLen := colon + 1 + size + 1
+	if len(buf) < cmdLen {
+		return Command{}, 0
+	}
+
+	return Command{Name: string(buf[:colon]), Payload: bytes.Clone(buf[colon+1 : cmdLen-1])}, cmdLen
+}
@@ -0,0 +1,109 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// In push mode the device stops prompting and takes commands as they come.
+// It grants credits instead, `credit:<n>` once in reply to push with how
+// many commands it can buffer, then `credit:1` whenever it is done with
+// one. Hosts that pace themselves, or use RTS/CTS, can ignore credits.
+
+const (
+	CmdPush     = "push:"
+	TokenCredit = "credit:"
+
+	DefaultCreditTimeout = time.Second
+
+	maxCredits = 256
+)
+
+var (
+	ErrNoCredit = errors.New("protocol: error, device granted no credit in time")
+)
+
+// PushCommand asks the device to switch to push mode.
+func PushCommand() []byte {
+	return []byte(CmdPush + "1\n")
+}
+
+func CreditReply(credits int) []byte {
+	return fmt.Appendf(nil, "%s%d\n", TokenCredit, credits)
+}
+
+func ParseCredit(token string) (int, bool) {
+	value, ok := strings.CutPrefix(token, TokenCredit)
+	if !ok {
+		return 0, false
+	}
+
+	credits, err := strconv.Atoi(value)
+	if err != nil || credits < 0 {
+		return 0, false
+	}
+
+	return credits, true
+}
+
+// CreditConn spends a credit on every Write, waiting up to Timeout for the
+// device to grant one. Credit tokens are taken out of what Read returns.
+type CreditConn struct {
+	conn   io.ReadWriteCloser
+	tokens *tokenSplitter
+
+	Timeout time.Duration
+
+	credits chan struct{}
+}
+
+func NewCreditConn(conn io.ReadWriteCloser) *CreditConn {
+	cc := &CreditConn{
+		conn:    conn,
+		Timeout: DefaultCreditTimeout,
+		credits: make(chan struct{}, maxCredits),
+	}
+	cc.tokens = newTokenSplitter(conn, cc.takeCredit)
+
+	return cc
+}
+
+func (cc *CreditConn) takeCredit(token string) bool {
+	credits, ok := ParseCredit(token)
+	if !ok {
+		return false
+	}
+
+	for range credits {
+		select {
+		case cc.credits <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+func (cc *CreditConn) Read(p []byte) (int, error) {
+	return cc.tokens.Read(p)
+}
+
+func (cc *CreditConn) Write(p []byte) (int, error) {
+	select {
+	case <-cc.credits:
+	case <-time.After(cc.Timeout):
+		return 0, ErrNoCredit
+	case <-cc.tokens.done:
+		return 0, cc.tokens.err()
+	}
+
+	return cc.conn.Write(p)
+}
+
+func (cc *CreditConn) Close() error {
+	return cc.conn.Close()
+}
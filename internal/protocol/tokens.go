@@ -0,0 +1,84 @@
+package protocol
+
+import (
+	"io"
+	"sync"
+)
+
+// tokenSplitter reads tokens off conn in the background. Tokens that take
+// claims are kept from Read, the rest are handed to Read in the order they
+// came, so the device can keep talking while a writer waits on it.
+type tokenSplitter struct {
+	conn io.Reader
+	take func(token string) bool
+	done chan struct{}
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	inbuf   []byte
+	readErr error
+}
+
+func newTokenSplitter(conn io.Reader, take func(token string) bool) *tokenSplitter {
+	ts := &tokenSplitter{
+		conn: conn,
+		take: take,
+		done: make(chan struct{}),
+	}
+	ts.cond = sync.NewCond(&ts.mu)
+
+	go ts.readLoop()
+
+	return ts
+}
+
+func (ts *tokenSplitter) readLoop() {
+	defer close(ts.done)
+
+	for {
+		token, err := ReadToken(ts.conn)
+		if err != nil {
+			ts.mu.Lock()
+			ts.readErr = err
+			ts.cond.Broadcast()
+			ts.mu.Unlock()
+
+			return
+		}
+
+		if ts.take(token) {
+			continue
+		}
+
+		ts.mu.Lock()
+		ts.inbuf = append(ts.inbuf, token+"\n"...)
+		ts.cond.Broadcast()
+		ts.mu.Unlock()
+	}
+}
+
+func (ts *tokenSplitter) Read(p []byte) (int, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for len(ts.inbuf) == 0 && ts.readErr == nil {
+		ts.cond.Wait()
+	}
+
+	if len(ts.inbuf) > 0 {
+		n := copy(p, ts.inbuf)
+		ts.inbuf = ts.inbuf[n:]
+
+		return n, nil
+	}
+
+	return 0, ts.readErr
+}
+
+// err is what stopped the read loop, valid once done is closed.
+func (ts *tokenSplitter) err() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.readErr
+}
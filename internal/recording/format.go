@@ -0,0 +1,256 @@
+package recording
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// A recording starts with a header:
+//
+//	"SZBR" | version | cols | rows
+//
+// followed by records:
+//
+//	kind | uvarint microseconds since previous record | payload
+//
+// Full frames carry cols*rows bytes. Delta frames carry a uvarint span
+// count, then for each span a uvarint offset, a uvarint length and the
+// changed bytes. Clear records carry no payload.
+
+const (
+	magic   = "SZBR"
+	version = 1
+)
+
+type Kind byte
+
+const (
+	KindFrame Kind = iota + 1
+	KindDelta
+	KindClear
+)
+
+var (
+	ErrBadMagic   = errors.New("recording: error, not a szb recording")
+	ErrBadVersion = errors.New("recording: error, unsupported recording version")
+	ErrBadRecord  = errors.New("recording: error, malformed record")
+	ErrBadSize    = errors.New("recording: error, displays up to 255x255 can be recorded")
+)
+
+// Record is one entry of a recording, Frame always holds the complete
+// frame after the record is applied.
+type Record struct {
+	Kind    Kind
+	Elapsed time.Duration
+	Frame   []byte
+}
+
+type Writer struct {
+	w          *bufio.Writer
+	frame      []byte
+	lastRecord time.Time
+	started    bool
+}
+
+func NewWriter(w io.Writer, cols, rows int) (*Writer, error) {
+	if cols < 1 || cols > 255 || rows < 1 || rows > 255 {
+		return nil, fmt.Errorf("%w: %dx%d", ErrBadSize, cols, rows)
+	}
+
+	bw := bufio.NewWriter(w)
+
+	if _, err := bw.Write([]byte{magic[0], magic[1], magic[2], magic[3], version, byte(cols), byte(rows)}); err != nil {
+		return nil, err
+	}
+
+	return &Writer{
+		w:     bw,
+		frame: bytes.Repeat([]byte{' '}, cols*rows),
+	}, nil
+}
+
+func (rw *Writer) WriteFrame(at time.Time, frame []byte) error {
+	if len(frame) != len(rw.frame) {
+		return fmt.Errorf("recording: error, frame is %d bytes, expecting %d", len(frame), len(rw.frame))
+	}
+
+	delta := encodeDelta(rw.frame, frame)
+
+	var err error
+	if rw.started && len(delta) < len(frame) {
+		err = rw.writeRecord(at, KindDelta, delta)
+	} else {
+		err = rw.writeRecord(at, KindFrame, frame)
+	}
+
+	copy(rw.frame, frame)
+
+	return err
+}
+
+func (rw *Writer) WriteClear(at time.Time) error {
+	copy(rw.frame, bytes.Repeat([]byte{' '}, len(rw.frame)))
+
+	return rw.writeRecord(at, KindClear, nil)
+}
+
+func (rw *Writer) Flush() error {
+	return rw.w.Flush()
+}
+
+func (rw *Writer) writeRecord(at time.Time, kind Kind, payload []byte) error {
+	var elapsed time.Duration
+	if rw.started {
+		elapsed = at.Sub(rw.lastRecord)
+	}
+
+	rw.started = true
+	rw.lastRecord = at
+
+	record := binary.AppendUvarint([]byte{byte(kind)}, uint64(elapsed.Microseconds()))
+	record = append(record, payload...)
+
+	_, err := rw.w.Write(record)
+
+	return err
+}
+
+func encodeDelta(prev, next []byte) []byte {
+	var (
+		spans [][2]int
+		start = -1
+	)
+
+	for idx := range next {
+		if prev[idx] != next[idx] {
+			if start < 0 {
+				start = idx
+			}
+			continue
+		}
+
+		if start >= 0 {
+			spans = append(spans, [2]int{start, idx})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, [2]int{start, len(next)})
+	}
+
+	result := binary.AppendUvarint(nil, uint64(len(spans)))
+	for _, span := range spans {
+		result = binary.AppendUvarint(result, uint64(span[0]))
+		result = binary.AppendUvarint(result, uint64(span[1]-span[0]))
+		result = append(result, next[span[0]:span[1]]...)
+	}
+
+	return result
+}
+
+type Reader struct {
+	r          *bufio.Reader
+	cols, rows int
+	frame      []byte
+	elapsed    time.Duration
+}
+
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, len(magic)+3)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, err
+	}
+
+	if string(header[:len(magic)]) != magic {
+		return nil, ErrBadMagic
+	}
+
+	if header[len(magic)] != version {
+		return nil, ErrBadVersion
+	}
+
+	cols, rows := int(header[len(magic)+1]), int(header[len(magic)+2])
+
+	return &Reader{
+		r:     br,
+		cols:  cols,
+		rows:  rows,
+		frame: bytes.Repeat([]byte{' '}, cols*rows),
+	}, nil
+}
+
+func (rr *Reader) Geometry() (int, int) {
+	return rr.cols, rr.rows
+}
+
+// Next returns the next record, or io.EOF at the end of the recording.
+func (rr *Reader) Next() (Record, error) {
+	kind, err := rr.r.ReadByte()
+	if err != nil {
+		return Record{}, err
+	}
+
+	micros, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return Record{}, ErrBadRecord
+	}
+
+	rr.elapsed += time.Duration(micros) * time.Microsecond
+
+	switch Kind(kind) {
+	case KindFrame:
+		if _, err := io.ReadFull(rr.r, rr.frame); err != nil {
+			return Record{}, ErrBadRecord
+		}
+	case KindDelta:
+		if err := rr.applyDelta(); err != nil {
+			return Record{}, err
+		}
+	case KindClear:
+		copy(rr.frame, bytes.Repeat([]byte{' '}, len(rr.frame)))
+	default:
+		return Record{}, ErrBadRecord
+	}
+
+	return Record{
+		Kind:    Kind(kind),
+		Elapsed: rr.elapsed,
+		Frame:   bytes.Clone(rr.frame),
+	}, nil
+}
+
+func (rr *Reader) applyDelta() error {
+	count, err := binary.ReadUvarint(rr.r)
+	if err != nil {
+		return ErrBadRecord
+	}
+
+	size := uint64(len(rr.frame))
+
+	for range count {
+		offset, err := binary.ReadUvarint(rr.r)
+		if err != nil {
+			return ErrBadRecord
+		}
+
+		// Checked apart so a huge offset can not wrap the sum around.
+		length, err := binary.ReadUvarint(rr.r)
+		if err != nil || offset > size || length > size-offset {
+			return ErrBadRecord
+		}
+
+		if _, err := io.ReadFull(rr.r, rr.frame[offset:offset+length]); err != nil {
+			return ErrBadRecord
+		}
+	}
+
+	return nil
+}
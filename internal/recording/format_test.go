@@ -0,0 +1,198 @@
+package recording
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+
+	writer, err := NewWriter(&out, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	frames := []string{"abcdefgh", "abXdefgY", "12345678"}
+
+	for idx, frame := range frames {
+		if err := writer.WriteFrame(start.Add(time.Duration(idx)*100*time.Millisecond), []byte(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.WriteClear(start.Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writer.WriteFrame(start.Add(time.Second), []byte("short")); err == nil {
+		t.Error("WriteFrame took a frame of the wrong size")
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range [][2]int{{256, 4}, {20, 300}, {0, 2}} {
+		if _, err := NewWriter(io.Discard, size[0], size[1]); !errors.Is(err, ErrBadSize) {
+			t.Errorf("NewWriter %dx%d = %v, want ErrBadSize", size[0], size[1], err)
+		}
+	}
+
+	reader, err := NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cols, rows := reader.Geometry(); cols != 4 || rows != 2 {
+		t.Errorf("geometry = %dx%d, want 4x2", cols, rows)
+	}
+
+	want := []Record{
+		{Kind: KindFrame, Elapsed: 0, Frame: []byte("abcdefgh")},
+		{Kind: KindDelta, Elapsed: 100 * time.Millisecond, Frame: []byte("abXdefgY")},
+		{Kind: KindFrame, Elapsed: 200 * time.Millisecond, Frame: []byte("12345678")},
+		{Kind: KindClear, Elapsed: time.Second, Frame: []byte("        ")},
+	}
+
+	for _, w := range want {
+		got, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Kind != w.Kind || got.Elapsed != w.Elapsed || !bytes.Equal(got.Frame, w.Frame) {
+			t.Errorf("record = %d %s %q, want %d %s %q", got.Kind, got.Elapsed, got.Frame, w.Kind, w.Elapsed, w.Frame)
+		}
+	}
+
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		t.Errorf("past the end = %v, want EOF", err)
+	}
+}
+
+func TestCorrupt(t *testing.T) {
+	header := []byte{'S', 'Z', 'B', 'R', version, 4, 2}
+
+	delta := func(offset, length uint64) []byte {
+		record := append(bytes.Clone(header), byte(KindDelta), 0, 1)
+		record = binary.AppendUvarint(record, offset)
+		record = binary.AppendUvarint(record, length)
+
+		return append(record, "xy"...)
+	}
+
+	headers := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"magic", []byte("GIF89a\x00"), ErrBadMagic},
+		{"version", []byte{'S', 'Z', 'B', 'R', 9, 4, 2}, ErrBadVersion},
+		{"truncated header", []byte("SZB"), io.ErrUnexpectedEOF},
+	}
+
+	for _, c := range headers {
+		if _, err := NewReader(bytes.NewReader(c.data)); !errors.Is(err, c.want) {
+			t.Errorf("%s: NewReader = %v, want %v", c.name, err, c.want)
+		}
+	}
+
+	records := []struct {
+		name string
+		data []byte
+	}{
+		{"unknown kind", append(bytes.Clone(header), 0x7f, 0)},
+		{"truncated frame", append(bytes.Clone(header), byte(KindFrame), 0, 'a', 'b')},
+		{"truncated elapsed", append(bytes.Clone(header), byte(KindFrame), 0x80)},
+		{"span past the end", delta(7, 2)},
+		{"offset past the end", delta(9, 0)},
+		{"span wrapping around", delta(1<<64-1, 2)},
+		{"truncated span", delta(0, 4)},
+	}
+
+	for _, c := range records {
+		reader, err := NewReader(bytes.NewReader(c.data))
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+
+		if _, err := reader.Next(); !errors.Is(err, ErrBadRecord) {
+			t.Errorf("%s: Next = %v, want ErrBadRecord", c.name, err)
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	var out bytes.Buffer
+
+	writer, err := NewWriter(&out, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writer.WriteFrame(start, []byte("a "))
+	writer.WriteFrame(start.Add(250*time.Millisecond), []byte("b "))
+	writer.WriteClear(start.Add(400 * time.Millisecond))
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, err := Sample(reader, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(bytes.Join(frames, []byte("|"))); got != "a |a |a |b |  " {
+		t.Errorf("frames = %q", got)
+	}
+}
+
+func TestFrames(t *testing.T) {
+	var out bytes.Buffer
+
+	writer, err := NewWriter(&out, 2, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	writer.WriteFrame(start, []byte("a "))
+	writer.WriteFrame(start.Add(3*time.Hour), []byte("b "))
+	writer.WriteClear(start.Add(3*time.Hour + 250*time.Millisecond))
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	reader, err := NewReader(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	frames, delays, err := Frames(reader, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := string(bytes.Join(frames, []byte("|"))); got != "a |b |  " {
+		t.Errorf("frames = %q", got)
+	}
+
+	if want := []time.Duration{3 * time.Hour, 250 * time.Millisecond, time.Second}; !slices.Equal(delays, want) {
+		t.Errorf("delays = %v, want %v", delays, want)
+	}
+}
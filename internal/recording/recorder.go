@@ -0,0 +1,57 @@
+package recording
+
+import (
+	"io"
+	"time"
+
+	"github.com/fudanchii/szb/internal/driver"
+)
+
+// Recorder sits between the app and the driver of a display, everything
+// passes through untouched while frames and clears get recorded.
+type Recorder struct {
+	driver.Driver
+
+	out    io.Closer
+	writer *Writer
+	err    error
+}
+
+func NewRecorder(drv driver.Driver, out io.WriteCloser, cols, rows int) (*Recorder, error) {
+	writer, err := NewWriter(out, cols, rows)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Recorder{
+		Driver: drv,
+		out:    out,
+		writer: writer,
+	}, nil
+}
+
+func (rec *Recorder) Frame(frame []byte) []byte {
+	rec.keep(rec.writer.WriteFrame(time.Now(), frame))
+
+	return rec.Driver.Frame(frame)
+}
+
+func (rec *Recorder) Clear() []byte {
+	rec.keep(rec.writer.WriteClear(time.Now()))
+
+	return rec.Driver.Clear()
+}
+
+// keep holds on to the first error, Close returns it.
+func (rec *Recorder) keep(err error) {
+	if rec.err == nil {
+		rec.err = err
+	}
+}
+
+func (rec *Recorder) Close() error {
+	rec.keep(rec.writer.Flush())
+	rec.keep(rec.out.Close())
+
+	return rec.err
+}
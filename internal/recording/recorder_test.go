@@ -0,0 +1,102 @@
+package recording
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/driver"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+// device prompts for every command and keeps what it was sent.
+type device struct {
+	prompts *strings.Reader
+	got     bytes.Buffer
+}
+
+func newDevice(prompts int) *device {
+	return &device{prompts: strings.NewReader(strings.Repeat(protocol.CmdPrompt+"\n", prompts))}
+}
+
+func (dev *device) Read(p []byte) (int, error) {
+	return dev.prompts.Read(p)
+}
+
+func (dev *device) Write(p []byte) (int, error) {
+	return dev.got.Write(p)
+}
+
+func (dev *device) Close() error {
+	return nil
+}
+
+type nopCloser struct {
+	*bytes.Buffer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var recorded bytes.Buffer
+
+	live := newDevice(0)
+	recorder, err := NewRecorder(driver.Szb{}, nopCloser{&recorded}, 4, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cmd := range [][]byte{
+		recorder.Frame([]byte("abcdefgh")),
+		recorder.Clear(),
+		recorder.Frame([]byte("abcdefgY")),
+	} {
+		live.Write(cmd)
+	}
+
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := string(bytes.Join([][]byte{
+		protocol.DisplayCommand([]byte("abcdefgh")),
+		protocol.ClearCommand(),
+		protocol.DisplayCommand([]byte("abcdefgY")),
+	}, nil))
+	if live.got.String() != want {
+		t.Errorf("device got %q, want %q", live.got.String(), want)
+	}
+
+	reader, err := NewReader(bytes.NewReader(recorded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replayed := newDevice(3)
+	if err := Replay(reader, replayed, driver.Szb{}, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if replayed.got.String() != want {
+		t.Errorf("replayed %q, want %q", replayed.got.String(), want)
+	}
+
+	// Displays that never prompt are played back without waiting.
+	reader, err = NewReader(bytes.NewReader(recorded.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mo := driver.NewMatrixOrbital(display.Geometry{Cols: 4, Rows: 2})
+	silent := newDevice(0)
+	if err := Replay(reader, silent, mo, 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.HasPrefix(silent.got.Bytes(), mo.Init()) || !bytes.HasSuffix(silent.got.Bytes(), mo.Frame([]byte("abcdefgY"))) {
+		t.Errorf("replayed %q through %s", silent.got.String(), mo)
+	}
+}
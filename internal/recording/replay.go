@@ -0,0 +1,140 @@
+package recording
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/fudanchii/szb/internal/driver"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+var (
+	ErrDeviceGone = errors.New("recording: error, device stopped prompting")
+)
+
+// Replay plays a recording back to a device through drv. Every record
+// waits for the device prompt first when the device prompts at all, then
+// for its original time scaled by 1/speed.
+func Replay(rr *Reader, device io.ReadWriter, drv driver.Driver, speed float64) error {
+	scanner := bufio.NewScanner(device)
+	scanner.Split(bufio.ScanWords)
+
+	_, prompts := drv.(driver.Szb)
+
+	if init := drv.Init(); len(init) > 0 {
+		if _, err := device.Write(init); err != nil {
+			return err
+		}
+	}
+
+	startedAt := time.Now()
+
+	for {
+		record, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if prompts {
+			if err := waitPrompt(scanner); err != nil {
+				return err
+			}
+		}
+
+		if wait := time.Until(startedAt.Add(time.Duration(float64(record.Elapsed) / speed))); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		cmd := drv.Frame(record.Frame)
+		if record.Kind == KindClear {
+			cmd = drv.Clear()
+		}
+
+		if _, err := device.Write(cmd); err != nil {
+			return err
+		}
+	}
+}
+
+// Frames reads every frame of a recording with how long it stayed on
+// display, the last frame has nothing after it and is held for last.
+func Frames(rr *Reader, last time.Duration) ([][]byte, []time.Duration, error) {
+	var (
+		frames [][]byte
+		delays []time.Duration
+		prev   time.Duration
+	)
+
+	for {
+		record, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			return frames, delays, nil
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(delays) > 0 {
+			delays[len(delays)-1] = record.Elapsed - prev
+		}
+
+		frames = append(frames, record.Frame)
+		delays = append(delays, last)
+		prev = record.Elapsed
+	}
+}
+
+// Sample steps through a recording every interval and returns the frame
+// on display at each step, the last frame comes once at the end.
+func Sample(rr *Reader, interval time.Duration) ([][]byte, error) {
+	var (
+		frames  [][]byte
+		current []byte
+		at      time.Duration
+	)
+
+	for {
+		record, err := rr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for current != nil && at < record.Elapsed {
+			frames = append(frames, current)
+			at += interval
+		}
+
+		current = record.Frame
+	}
+
+	if current != nil {
+		frames = append(frames, current)
+	}
+
+	return frames, nil
+}
+
+func waitPrompt(scanner *bufio.Scanner) error {
+	for scanner.Scan() {
+		if scanner.Text() == protocol.CmdPrompt {
+			return nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	return ErrDeviceGone
+}
@@ -0,0 +1,113 @@
+package settings
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// File keeps flag values as `name=value` lines, blank lines and lines
+// starting with # are skipped.
+type File struct {
+	path string
+
+	mu     sync.Mutex
+	values map[string]string
+	order  []string
+}
+
+// Load reads path, a missing file is just an empty one.
+func Load(path string) (*File, error) {
+	file := &File{path: path, values: make(map[string]string)}
+
+	in, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	scanner := bufio.NewScanner(in)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("settings: error parsing %s line %d, expecting name=value", path, lineNo)
+		}
+
+		file.Set(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+
+	return file, scanner.Err()
+}
+
+// Apply sets every flag that was not given on the command line from the
+// file, the command line wins.
+func (f *File) Apply(flags *flag.FlagSet) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	given := map[string]bool{}
+	flags.Visit(func(fl *flag.Flag) {
+		given[fl.Name] = true
+	})
+
+	for _, name := range f.order {
+		if given[name] {
+			continue
+		}
+
+		if err := flags.Set(name, f.values[name]); err != nil {
+			return fmt.Errorf("settings: error applying %s from %s: %w", name, f.path, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *File) Set(name, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.values[name]; !ok {
+		f.order = append(f.order, name)
+	}
+
+	f.values[name] = value
+}
+
+// Save writes the file back through a temporary file so a crash never
+// leaves it half written. Comments from the original file are not kept.
+func (f *File) Save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o755); err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	for _, name := range f.order {
+		fmt.Fprintf(&sb, "%s=%s\n", name, f.values[name])
+	}
+
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.path)
+}
@@ -0,0 +1,58 @@
+package settings
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "szb", "szb.conf")
+
+	file, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file.Set("t", "Asia/Tokyo")
+	file.Set("o", "t,em,em,em")
+
+	if err := file.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(content) != "t=Asia/Tokyo\no=t,em,em,em\n" {
+		t.Errorf("saved %q", content)
+	}
+
+	flags := flag.NewFlagSet("szb", flag.ContinueOnError)
+	timezone := flags.String("t", "UTC", "")
+	overflow := flags.String("o", "wrap", "")
+
+	if err := flags.Parse([]string{"-o", "t,t,t,t"}); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err = Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := file.Apply(flags); err != nil {
+		t.Fatal(err)
+	}
+
+	if *timezone != "Asia/Tokyo" {
+		t.Errorf("timezone = %q, want it from the file", *timezone)
+	}
+
+	if *overflow != "t,t,t,t" {
+		t.Errorf("overflow = %q, want the command line to win", *overflow)
+	}
+}
@@ -2,6 +2,7 @@ package sysstats
 
 import (
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/fudanchii/szb/internal/humanreadable"
@@ -10,11 +11,18 @@ import (
 	"github.com/mackerelio/go-osstat/uptime"
 )
 
+const (
+	// CPU_HISTORY is how many seconds of CPU usage are kept for graphs.
+	CPU_HISTORY = 128
+)
+
 type Aggregates struct {
+	mu              sync.Mutex
 	prevCPUStats    *cpu.Stats
 	currentCPUStats *cpu.Stats
 	memStats        *memory.Stats
 	uptime          time.Duration
+	cpuHistory      []float64
 }
 
 func NewAggregates() (*Aggregates, error) {
@@ -47,21 +55,43 @@ func NewAggregates() (*Aggregates, error) {
 
 func (aggr *Aggregates) populateStatsInfo() {
 	for {
+		cpuStats, _ := cpu.Get()
+		up, _ := uptime.Get()
+		memStats, _ := memory.Get()
+
+		aggr.mu.Lock()
 		aggr.prevCPUStats = aggr.currentCPUStats
-		aggr.currentCPUStats, _ = cpu.Get()
-		aggr.uptime, _ = uptime.Get()
-		aggr.memStats, _ = memory.Get()
+		aggr.currentCPUStats = cpuStats
+		aggr.uptime = up
+		aggr.memStats = memStats
+		aggr.mu.Unlock()
+
+		_, _, idlCpu := aggr.cpuUsage()
+
+		aggr.mu.Lock()
+		aggr.cpuHistory = append(aggr.cpuHistory, 100-idlCpu)
+		if len(aggr.cpuHistory) > CPU_HISTORY {
+			aggr.cpuHistory = aggr.cpuHistory[1:]
+		}
+		aggr.mu.Unlock()
 
 		time.Sleep(1 * time.Second)
 	}
 }
 
-func (aggr *Aggregates) String() string {
-	cpuTotal := float64(aggr.currentCPUStats.Total - aggr.prevCPUStats.Total)
+// Line adapts a function to fmt.Stringer so a single stat can be used as
+// a line source.
+type Line func() string
 
-	usrCpu := float64(0)
-	sysCpu := float64(0)
-	idlCpu := float64(0)
+func (l Line) String() string {
+	return l()
+}
+
+func (aggr *Aggregates) cpuUsage() (usrCpu, sysCpu, idlCpu float64) {
+	aggr.mu.Lock()
+	defer aggr.mu.Unlock()
+
+	cpuTotal := float64(aggr.currentCPUStats.Total - aggr.prevCPUStats.Total)
 
 	if cpuTotal != 0 {
 		usrCpu = float64(aggr.currentCPUStats.User-aggr.prevCPUStats.User) / cpuTotal * 100
@@ -69,6 +99,52 @@ func (aggr *Aggregates) String() string {
 		idlCpu = float64(aggr.currentCPUStats.Idle-aggr.prevCPUStats.Idle) / cpuTotal * 100
 	}
 
+	return
+}
+
+func (aggr *Aggregates) CPU() fmt.Stringer {
+	return Line(func() string {
+		usrCpu, sysCpu, idlCpu := aggr.cpuUsage()
+
+		return fmt.Sprintf("cpu %.0f%%u %.0f%%s %.0f%%i", usrCpu, sysCpu, idlCpu)
+	})
+}
+
+// CPUHistory returns the busy percentage of the last CPU_HISTORY
+// seconds, oldest first.
+func (aggr *Aggregates) CPUHistory() []float64 {
+	aggr.mu.Lock()
+	defer aggr.mu.Unlock()
+
+	return append([]float64{}, aggr.cpuHistory...)
+}
+
+func (aggr *Aggregates) Memory() fmt.Stringer {
+	return Line(func() string {
+		aggr.mu.Lock()
+		defer aggr.mu.Unlock()
+
+		return fmt.Sprintf("mem %s/%s",
+			humanreadable.BiBytes(aggr.memStats.Total-aggr.memStats.Available),
+			humanreadable.BiBytes(aggr.memStats.Total))
+	})
+}
+
+func (aggr *Aggregates) Uptime() fmt.Stringer {
+	return Line(func() string {
+		aggr.mu.Lock()
+		defer aggr.mu.Unlock()
+
+		return fmt.Sprintf("up %v", humanreadable.Second(aggr.uptime))
+	})
+}
+
+func (aggr *Aggregates) String() string {
+	usrCpu, sysCpu, idlCpu := aggr.cpuUsage()
+
+	aggr.mu.Lock()
+	defer aggr.mu.Unlock()
+
 	return fmt.Sprintf("mem.total:%s, mem.avail:%s, mem.cached:%s, mem.act:%s, mem.inact:%s, mem.free:%s, cpu.usr:%.1f%%, cpu.sys:%.1f%%, cpu.idle:%.1f%%, up:%v",
 		humanreadable.BiBytes(aggr.memStats.Total),
 		humanreadable.BiBytes(aggr.memStats.Available),
@@ -2,52 +2,64 @@ package sysstats
 
 import (
 	"fmt"
+	"sync"
 	"time"
 )
 
 const (
-	ONE_SECOND_IN_MS  = 1000
 	ONE_MINUTE_PERIOD = 60
 )
 
+// DateTime shows the date, and the day of week for the last showDoWPeriod
+// seconds of every minute. It keeps no per-render state so several
+// displays can share it.
 type DateTime struct {
-	callCounter   int
-	renderRate    int
 	showDoWPeriod int
-	timezone      *time.Location
+
+	mu       sync.Mutex
+	timezone *time.Location
 }
 
-func NewDateTime(timezone string, renderRate, showDoWPeriod int) (*DateTime, error) {
+func NewDateTime(timezone string, showDoWPeriod int) (*DateTime, error) {
 	loc, err := time.LoadLocation(timezone)
 	if err != nil {
 		return nil, err
 	}
 
 	return &DateTime{
-		renderRate:    renderRate,
 		showDoWPeriod: showDoWPeriod,
 		timezone:      loc,
 	}, nil
 }
 
-func (dt *DateTime) String() string {
-	var result string
+func (dt *DateTime) SetTimezone(timezone string) error {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return err
+	}
 
-	now := time.Now().In(dt.timezone)
+	dt.mu.Lock()
+	dt.timezone = loc
+	dt.mu.Unlock()
+
+	return nil
+}
 
-	waitUnit := ONE_SECOND_IN_MS / dt.renderRate
-	startDoWAt := (ONE_MINUTE_PERIOD - dt.showDoWPeriod) * waitUnit
-	if dt.callCounter >= startDoWAt {
-		if dt.callCounter > ONE_MINUTE_PERIOD*waitUnit {
-			dt.callCounter = 0
-		}
+func (dt *DateTime) Timezone() string {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
 
-		result = fmt.Sprintf("%-12s%s", now.Format("Monday"), now.Format("15:04:05"))
-	} else {
-		result = now.Format("2006-01-02  15:04:05")
-	}
+	return dt.timezone.String()
+}
 
-	dt.callCounter++
+func (dt *DateTime) String() string {
+	dt.mu.Lock()
+	now := time.Now().In(dt.timezone)
+	dt.mu.Unlock()
+
+	if now.Second() >= ONE_MINUTE_PERIOD-dt.showDoWPeriod {
+		return fmt.Sprintf("%-12s%s", now.Format("Monday"), now.Format("15:04:05"))
+	}
 
-	return result
+	return now.Format("2006-01-02  15:04:05")
 }
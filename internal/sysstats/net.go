@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync"
 	"time"
 )
 
 type NetworkStats struct {
+	mu              sync.Mutex
 	ifaceInfoBuffer string
 }
 
@@ -54,11 +56,16 @@ func (nstats *NetworkStats) populateIfaceInfo() error {
 		ifaceList = append(ifaceList, fmt.Sprintf("%s ~ %s", iface.Name, strings.Join(addrsList, ", ")))
 	}
 
+	nstats.mu.Lock()
 	nstats.ifaceInfoBuffer = strings.Join(ifaceList, " | ")
+	nstats.mu.Unlock()
 
 	return nil
 }
 
 func (nstats *NetworkStats) String() string {
+	nstats.mu.Lock()
+	defer nstats.mu.Unlock()
+
 	return nstats.ifaceInfoBuffer
 }
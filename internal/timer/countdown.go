@@ -0,0 +1,120 @@
+package timer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Countdown counts a set time down to zero once started. It can be paused
+// and started again, and calls done when it runs out.
+type Countdown struct {
+	mu       sync.Mutex
+	set      time.Duration
+	left     time.Duration
+	deadline time.Time
+	running  bool
+	alarm    *time.Timer
+	done     func()
+}
+
+func NewCountdown(set time.Duration, done func()) *Countdown {
+	return &Countdown{
+		set:  set,
+		left: set,
+		done: done,
+	}
+}
+
+// Toggle starts the countdown, or pauses it when it is running. Once it
+// ran out it is set back to the set time instead.
+func (c *Countdown) Toggle() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.running:
+		c.left = time.Until(c.deadline)
+		c.running = false
+		c.alarm.Stop()
+	case c.left <= 0:
+		c.left = c.set
+	default:
+		c.deadline = time.Now().Add(c.left)
+		c.running = true
+		c.alarm = time.AfterFunc(c.left, c.ring)
+	}
+}
+
+// Reset stops the countdown and sets it back to the set time.
+func (c *Countdown) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		c.alarm.Stop()
+	}
+
+	c.running = false
+	c.left = c.set
+}
+
+// Adjust changes the set time by delta while the countdown stands still,
+// down to a minute at least, and reports whether it did.
+func (c *Countdown) Adjust(delta time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.running {
+		return false
+	}
+
+	c.set = max(c.set+delta, time.Minute)
+	c.left = c.set
+
+	return true
+}
+
+func (c *Countdown) Running() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.running
+}
+
+func (c *Countdown) ring() {
+	c.mu.Lock()
+	if !c.running || time.Now().Before(c.deadline) {
+		c.mu.Unlock()
+		return
+	}
+
+	c.running = false
+	c.left = 0
+	c.mu.Unlock()
+
+	if c.done != nil {
+		c.done()
+	}
+}
+
+// String shows the time left, rounded up to whole seconds so zero only
+// shows once it ran out.
+func (c *Countdown) String() string {
+	c.mu.Lock()
+	left, state := c.left, "paused"
+
+	switch {
+	case c.running:
+		left, state = time.Until(c.deadline), "running"
+	case c.left == c.set:
+		state = "ready"
+	case c.left <= 0:
+		state = "done"
+	}
+	c.mu.Unlock()
+
+	secs := int((max(left, 0) + time.Second - 1) / time.Second)
+
+	return fmt.Sprintf("Timer %02d:%02d %s", secs/60, secs%60, state)
+}
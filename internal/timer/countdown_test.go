@@ -0,0 +1,50 @@
+package timer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountdown(t *testing.T) {
+	rang := make(chan struct{}, 1)
+	countdown := NewCountdown(5*time.Minute, func() { rang <- struct{}{} })
+
+	if got := countdown.String(); got != "Timer 05:00 ready" {
+		t.Errorf("String = %q", got)
+	}
+
+	if !countdown.Adjust(-10*time.Minute) || countdown.String() != "Timer 01:00 ready" {
+		t.Errorf("set below a minute shows %q", countdown.String())
+	}
+
+	countdown.Toggle()
+	if countdown.Adjust(time.Minute) {
+		t.Error("Adjust changed a running countdown")
+	}
+
+	countdown.Toggle()
+	if got := countdown.String(); got != "Timer 01:00 paused" {
+		t.Errorf("paused right away shows %q", got)
+	}
+
+	countdown.Reset()
+
+	// Run a short one out.
+	countdown.set, countdown.left = 20*time.Millisecond, 20*time.Millisecond
+	countdown.Toggle()
+
+	select {
+	case <-rang:
+	case <-time.After(time.Second):
+		t.Fatal("the countdown never ran out")
+	}
+
+	if got := countdown.String(); got != "Timer 00:00 done" || countdown.Running() {
+		t.Errorf("run out shows %q, running %v", got, countdown.Running())
+	}
+
+	countdown.Toggle()
+	if countdown.Running() || countdown.left != countdown.set {
+		t.Error("Toggle after running out did not set the countdown back")
+	}
+}
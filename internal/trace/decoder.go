@@ -0,0 +1,200 @@
+package trace
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/input"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+const (
+	// stx starts every host command in framed mode.
+	stx = 0x02
+
+	// maxPending is how many frames worth of bytes that make no command
+	// are kept before they are given up on as unknown.
+	maxPending = 4
+)
+
+// Direction tells which way bytes went.
+type Direction byte
+
+const (
+	ToDevice   Direction = '>'
+	FromDevice Direction = '<'
+)
+
+// Decoder turns the bytes of both directions into readable lines. Frames
+// are laid out on the size the device reported in its caps, 20x4 until
+// it does.
+type Decoder struct {
+	geometry display.Geometry
+	host     []byte
+	device   []byte
+}
+
+func NewDecoder() *Decoder {
+	return &Decoder{geometry: display.DefaultGeometry}
+}
+
+// Reset forgets partial commands, e.g. after a reconnect. The size stays.
+func (d *Decoder) Reset() {
+	d.host = nil
+	d.device = nil
+}
+
+// Feed takes the next bytes that went dir and returns a line for every
+// command or token they complete.
+func (d *Decoder) Feed(dir Direction, data []byte) []string {
+	if dir == FromDevice {
+		d.device = append(d.device, data...)
+		return d.deviceTokens()
+	}
+
+	d.host = append(d.host, data...)
+
+	return d.hostCommands()
+}
+
+func (d *Decoder) frameSize() int {
+	return d.geometry.Cols * d.geometry.Rows
+}
+
+func (d *Decoder) hostCommands() []string {
+	lines := []string{}
+
+	for len(d.host) > 0 {
+		if d.host[0] == stx {
+			frame, n := protocol.NextFrame(d.host)
+			if frame == nil {
+				break
+			}
+
+			d.host = d.host[n:]
+
+			seq, payload, err := protocol.DecodeFrame(frame)
+			if err != nil {
+				lines = append(lines, fmt.Sprintf("bad frame: %v", err))
+				continue
+			}
+
+			cmd, _ := protocol.ParseCommand(append(payload, '\n'), d.frameSize())
+			for _, line := range d.describe(cmd) {
+				lines = append(lines, fmt.Sprintf("[seq %d] %s", seq, line))
+			}
+
+			continue
+		}
+
+		cmd, n := protocol.ParseCommand(d.host, d.frameSize())
+		if n == 0 {
+			if len(d.host) > maxPending*d.frameSize() {
+				lines = append(lines, fmt.Sprintf("unknown bytes %q", d.host))
+				d.host = nil
+			}
+
+			break
+		}
+
+		d.host = d.host[n:]
+		lines = append(lines, d.describe(cmd)...)
+	}
+
+	return lines
+}
+
+func (d *Decoder) describe(cmd protocol.Command) []string {
+	switch {
+	case cmd.Name == protocol.CmdDisplay:
+		lines := []string{"display"}
+		for _, row := range display.FrameRows(cmd.Payload, d.geometry.Cols, d.geometry.Rows) {
+			lines = append(lines, "|"+display.DecodeLCDCharMap(row)+"|")
+		}
+
+		return lines
+	case cmd.Name == protocol.CmdClear:
+		return []string{"clear"}
+	case strings.HasPrefix(cmd.Name, protocol.CmdFramebuffer):
+		fields := strings.Split(strings.TrimPrefix(cmd.Name, protocol.CmdFramebuffer), ",")
+		if len(fields) != 3 {
+			return []string{fmt.Sprintf("bad framebuffer command %q", cmd.Name)}
+		}
+
+		return []string{fmt.Sprintf("framebuffer page %s col %s, %d bytes", fields[0], fields[1], len(cmd.Payload))}
+	}
+
+	for _, known := range []string{
+		protocol.CmdHello,
+		protocol.CmdFramed,
+		protocol.CmdPush,
+		protocol.CmdBacklight,
+		protocol.CmdContrast,
+	} {
+		if strings.HasPrefix(cmd.Name, known) {
+			return []string{cmd.Name}
+		}
+	}
+
+	return []string{fmt.Sprintf("unknown command %q", cmd.Name)}
+}
+
+func (d *Decoder) deviceTokens() []string {
+	lines := []string{}
+
+	for {
+		idx := bytes.IndexAny(d.device, " \t\r\n")
+		if idx < 0 {
+			break
+		}
+
+		token := string(d.device[:idx])
+		d.device = d.device[idx+1:]
+
+		if token != "" {
+			lines = append(lines, d.token(token))
+		}
+	}
+
+	return lines
+}
+
+func (d *Decoder) token(token string) string {
+	if token == protocol.CmdPrompt {
+		return "prompt"
+	}
+
+	if strings.HasPrefix(token, protocol.CmdCaps) {
+		caps, err := protocol.ParseCapabilities(token)
+		if err != nil {
+			return fmt.Sprintf("bad caps %q: %v", token, err)
+		}
+
+		d.geometry = display.Geometry{Cols: caps.Cols, Rows: caps.Rows}
+
+		return fmt.Sprintf("caps %dx%d rom %s v%d", caps.Cols, caps.Rows, caps.ROM, caps.Version)
+	}
+
+	if credits, ok := protocol.ParseCredit(token); ok {
+		return fmt.Sprintf("credit %d", credits)
+	}
+
+	for _, prefix := range []string{protocol.TokenAck, protocol.TokenNak} {
+		if seq, ok := strings.CutPrefix(token, prefix); ok {
+			return strings.TrimSuffix(prefix, ":") + " " + seq
+		}
+	}
+
+	if event, ok := input.Parse(token); ok {
+		switch ev := event.(type) {
+		case input.ButtonEvent:
+			return fmt.Sprintf("button %d %s", ev.Button, ev.Action)
+		case input.EncoderEvent:
+			return fmt.Sprintf("encoder %+d", ev.Delta)
+		}
+	}
+
+	return fmt.Sprintf("unknown token %q", token)
+}
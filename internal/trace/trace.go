@@ -0,0 +1,204 @@
+// Package trace logs what goes over the wire to a display, every chunk
+// read or written as a timestamped hex record followed by what it decodes
+// to.
+package trace
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/transport"
+
+	"go.bug.st/serial"
+)
+
+const (
+	// noteMark starts records that are not traffic, e.g. a new connection.
+	noteMark = '*'
+	// decodedMark starts the decoded lines written after each record.
+	decodedMark = '#'
+)
+
+var (
+	ErrBadRecord = errors.New("trace: error parsing record, expected `<time> <direction> <hex>`")
+)
+
+// Log writes records of the traffic on one display.
+type Log struct {
+	mu      sync.Mutex
+	out     io.Writer
+	decoder *Decoder
+	now     func() time.Time
+}
+
+func NewLog(out io.Writer) *Log {
+	return &Log{out: out, decoder: NewDecoder(), now: time.Now}
+}
+
+// Record logs data that went dir.
+func (l *Log) Record(dir Direction, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fmt.Fprintf(l.out, "%s %c %s\n", l.timestamp(), dir, hex.EncodeToString(data))
+
+	for _, line := range l.decoder.Feed(dir, data) {
+		fmt.Fprintf(l.out, "%c %c %s\n", decodedMark, dir, line)
+	}
+}
+
+// Note logs something that is not traffic. Partial commands are dropped
+// since a note marks a new connection.
+func (l *Log) Note(text string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.decoder.Reset()
+	fmt.Fprintf(l.out, "%s %c %s\n", l.timestamp(), noteMark, text)
+}
+
+func (l *Log) timestamp() string {
+	return l.now().Format(time.RFC3339Nano)
+}
+
+// Transport traces every connection of the wrapped transport.
+type Transport struct {
+	transport.Transport
+	Log *Log
+
+	file *os.File
+}
+
+// Open starts a trace of inner in a new file at path.
+func Open(inner transport.Transport, path string) (*Transport, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Transport{Transport: inner, Log: NewLog(file), file: file}, nil
+}
+
+func (t *Transport) Open() (io.ReadWriteCloser, error) {
+	conn, err := t.Transport.Open()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Log.Note("open " + t.Transport.String())
+
+	traced := &tracedConn{ReadWriteCloser: conn, log: t.Log}
+	if lines, ok := conn.(modemLines); ok {
+		return &tracedSerialConn{tracedConn: traced, modemLines: lines}, nil
+	}
+
+	return traced, nil
+}
+
+// Close ends the trace and closes the inner transport when it can be
+// closed. Connections opened before are no longer logged to the file.
+func (t *Transport) Close() error {
+	var err error
+	if closer, ok := t.Transport.(io.Closer); ok {
+		err = closer.Close()
+	}
+
+	if t.file == nil {
+		return err
+	}
+
+	return errors.Join(err, t.file.Close())
+}
+
+type tracedConn struct {
+	io.ReadWriteCloser
+	log *Log
+}
+
+func (tc *tracedConn) Read(p []byte) (int, error) {
+	n, err := tc.ReadWriteCloser.Read(p)
+	tc.log.Record(FromDevice, p[:n])
+
+	return n, err
+}
+
+func (tc *tracedConn) Write(p []byte) (int, error) {
+	n, err := tc.ReadWriteCloser.Write(p)
+	tc.log.Record(ToDevice, p[:n])
+
+	return n, err
+}
+
+func (tc *tracedConn) Close() error {
+	tc.log.Note("close")
+	return tc.ReadWriteCloser.Close()
+}
+
+// modemLines are what RTS/CTS flow control drives on a serial port.
+type modemLines interface {
+	SetRTS(rts bool) error
+	GetModemStatusBits() (*serial.ModemStatusBits, error)
+}
+
+// tracedSerialConn keeps the modem lines of a serial port in reach, so
+// flow control works on traced connections.
+type tracedSerialConn struct {
+	*tracedConn
+	modemLines
+}
+
+// Decode reads a trace back and writes what every record decodes to,
+// the decoded lines already in the trace are ignored so traces from older
+// versions decode the same.
+func Decode(in io.Reader, out io.Writer) error {
+	decoder := NewDecoder()
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(nil, 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == decodedMark {
+			continue
+		}
+
+		stamp, rest, _ := strings.Cut(line, " ")
+		mark, data, ok := strings.Cut(rest, " ")
+		if !ok || len(mark) != 1 {
+			return fmt.Errorf("%w: %q", ErrBadRecord, line)
+		}
+
+		if mark[0] == noteMark {
+			decoder.Reset()
+			fmt.Fprintf(out, "%s %c %s\n", stamp, noteMark, data)
+
+			continue
+		}
+
+		dir := Direction(mark[0])
+		if dir != ToDevice && dir != FromDevice {
+			return fmt.Errorf("%w: %q", ErrBadRecord, line)
+		}
+
+		raw, err := hex.DecodeString(data)
+		if err != nil {
+			return fmt.Errorf("%w: %q", ErrBadRecord, line)
+		}
+
+		for _, decoded := range decoder.Feed(dir, raw) {
+			fmt.Fprintf(out, "%s %c %s\n", stamp, dir, decoded)
+		}
+	}
+
+	return scanner.Err()
+}
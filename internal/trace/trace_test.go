@@ -0,0 +1,168 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/protocol"
+	"github.com/fudanchii/szb/internal/transport"
+
+	"go.bug.st/serial"
+)
+
+func TestLogAndDecode(t *testing.T) {
+	var out bytes.Buffer
+
+	log := NewLog(&out)
+	log.now = func() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+
+	frame := bytes.Repeat([]byte{' '}, 16*2)
+	copy(frame[display.RowOffset(16, 2, 0):], "hello")
+	copy(frame[display.RowOffset(16, 2, 1):], "world")
+
+	cmd := protocol.DisplayCommand(frame)
+
+	log.Note("open test")
+	log.Record(ToDevice, protocol.HelloCommand())
+	log.Record(FromDevice, protocol.CapsReply(protocol.Capabilities{Version: 2, Cols: 16, Rows: 2, ROM: "A00", MaxRate: 20}))
+	log.Record(FromDevice, []byte("$>:"))
+	log.Record(FromDevice, []byte("\n"))
+	// Split writes only decode once the command is complete.
+	log.Record(ToDevice, cmd[:10])
+	log.Record(ToDevice, cmd[10:])
+	log.Record(ToDevice, protocol.EncodeFrame(7, protocol.ClearCommand()[:3]))
+	log.Record(FromDevice, []byte("btn:1:long enc:-2 ack:7 bogus\n"))
+
+	want := []string{
+		"* open test",
+		"> hello:2",
+		"< caps 16x2 rom A00 v2",
+		"< prompt",
+		"> display",
+		"> |hello           |",
+		"> |world           |",
+		"> [seq 7] clear",
+		"< button 1 long",
+		"< encoder -2",
+		"< ack 7",
+		`< unknown token "bogus"`,
+	}
+
+	decoded := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if rest, ok := strings.CutPrefix(line, "# "); ok {
+			decoded = append(decoded, rest)
+		}
+	}
+
+	if got := strings.Join(decoded, "\n"); got != strings.Join(want[1:], "\n") {
+		t.Errorf("decoded lines in the trace:\n%s\nwant:\n%s", got, strings.Join(want[1:], "\n"))
+	}
+
+	var replayed bytes.Buffer
+	if err := Decode(&out, &replayed); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(replayed.String()), "\n") {
+		stamp, rest, _ := strings.Cut(line, " ")
+		if stamp != "2024-05-01T12:00:00Z" {
+			t.Errorf("timestamp = %q", stamp)
+		}
+
+		lines = append(lines, rest)
+	}
+
+	if got := strings.Join(lines, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("Decode:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestDecodeBadRecord(t *testing.T) {
+	var out bytes.Buffer
+
+	if err := Decode(strings.NewReader("2024-05-01T12:00:00Z > zz\n"), &out); err == nil {
+		t.Error("Decode accepted a record that is not hex")
+	}
+}
+
+// serialConn stands in for a serial port with CTS always raised.
+type serialConn struct {
+	bytes.Buffer
+	rts bool
+}
+
+func (sc *serialConn) Close() error {
+	return nil
+}
+
+func (sc *serialConn) SetRTS(rts bool) error {
+	sc.rts = rts
+	return nil
+}
+
+func (sc *serialConn) GetModemStatusBits() (*serial.ModemStatusBits, error) {
+	return &serial.ModemStatusBits{CTS: true}, nil
+}
+
+type serialTransport struct {
+	conn *serialConn
+}
+
+func (st serialTransport) Open() (io.ReadWriteCloser, error) {
+	return st.conn, nil
+}
+
+func (st serialTransport) String() string {
+	return "serial:///dev/fake"
+}
+
+func TestTransportFlowControl(t *testing.T) {
+	conn := &serialConn{}
+
+	traced, err := Open(serialTransport{conn: conn}, filepath.Join(t.TempDir(), "trace.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer traced.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+
+	supervisor := transport.NewSupervisor(traced, done)
+	supervisor.Push = true
+	supervisor.Flow = transport.FlowRTSCTS
+
+	connected := make(chan error, 1)
+	go func() {
+		_, err := supervisor.Connect()
+		connected <- err
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RTS/CTS flow control does not get through a traced connection")
+	}
+
+	if !conn.rts {
+		t.Error("RTS left low")
+	}
+
+	if _, err := supervisor.Write(protocol.ClearCommand()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := conn.String(); got != string(protocol.PushCommand())+string(protocol.ClearCommand()) {
+		t.Errorf("device got %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+const (
+	ctsPollInterval    = 2 * time.Millisecond
+	defaultFlowTimeout = time.Second
+)
+
+var (
+	ErrUnknownFlow  = errors.New("transport: error, unknown flow control, use none, rtscts or credit")
+	ErrNoModemLines = errors.New("transport: error, RTS/CTS flow control needs a serial port")
+	ErrCTSTimeout   = errors.New("transport: error, device did not raise CTS in time")
+	ErrWriteTimeout = errors.New("transport: error, write timed out")
+)
+
+// Flow is how a host that does not wait for prompts keeps from overrunning
+// the device.
+type Flow int
+
+const (
+	FlowNone Flow = iota
+	// FlowRTSCTS holds every write until the device raises CTS.
+	FlowRTSCTS
+	// FlowCredit spends a device granted credit on every write.
+	FlowCredit
+)
+
+func ParseFlow(name string) (Flow, error) {
+	switch name {
+	case "", "none":
+		return FlowNone, nil
+	case "rtscts":
+		return FlowRTSCTS, nil
+	case "credit":
+		return FlowCredit, nil
+	}
+
+	return FlowNone, fmt.Errorf("%w: %s", ErrUnknownFlow, name)
+}
+
+func (f Flow) String() string {
+	switch f {
+	case FlowRTSCTS:
+		return "rtscts"
+	case FlowCredit:
+		return "credit"
+	}
+
+	return "none"
+}
+
+type modemLines interface {
+	SetRTS(rts bool) error
+	GetModemStatusBits() (*serial.ModemStatusBits, error)
+}
+
+// ctsConn raises RTS once open and waits for CTS before every write.
+type ctsConn struct {
+	io.ReadWriteCloser
+	lines   modemLines
+	timeout time.Duration
+}
+
+// newCTSConn hands conn back on error so the caller can still close it.
+func newCTSConn(conn io.ReadWriteCloser, timeout time.Duration) (io.ReadWriteCloser, error) {
+	lines, ok := conn.(modemLines)
+	if !ok {
+		return conn, ErrNoModemLines
+	}
+
+	if err := lines.SetRTS(true); err != nil {
+		return conn, err
+	}
+
+	return &ctsConn{ReadWriteCloser: conn, lines: lines, timeout: timeout}, nil
+}
+
+func (cc *ctsConn) Write(p []byte) (int, error) {
+	deadline := time.Now().Add(cc.timeout)
+
+	for {
+		bits, err := cc.lines.GetModemStatusBits()
+		if err != nil {
+			return 0, err
+		}
+
+		if bits.CTS {
+			return cc.ReadWriteCloser.Write(p)
+		}
+
+		if time.Now().After(deadline) {
+			return 0, ErrCTSTimeout
+		}
+
+		time.Sleep(ctsPollInterval)
+	}
+}
+
+// writeTimeout gives up on a write that takes longer than timeout. The
+// write goes on in the background until the caller closes conn.
+func writeTimeout(conn io.Writer, p []byte, timeout time.Duration) (int, error) {
+	if timeout <= 0 {
+		return conn.Write(p)
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+
+	written := make(chan result, 1)
+	go func() {
+		n, err := conn.Write(p)
+		written <- result{n, err}
+	}()
+
+	select {
+	case res := <-written:
+		return res.n, res.err
+	case <-time.After(timeout):
+		return 0, ErrWriteTimeout
+	}
+}
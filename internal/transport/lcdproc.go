@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/fudanchii/szb/internal/lcdproc"
+	"github.com/fudanchii/szb/internal/lcdsim"
+)
+
+const (
+	lcdprocClientName = "szb"
+)
+
+// LCDproc shows frames on whatever display an LCDd drives, as an LCDproc
+// client. A simulated device stands in for the firmware, so the rest of
+// szb talks to it like any other display of the size LCDd reports.
+type LCDproc struct {
+	Addr string
+}
+
+func (lp *LCDproc) Open() (io.ReadWriteCloser, error) {
+	addr := lp.Addr
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, strconv.Itoa(lcdproc.DefaultPort))
+	}
+
+	client, err := lcdproc.Dial(addr, lcdprocClientName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := lcdproc.NewOutput(client, lcdprocClientName)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	geometry := client.Geometry
+	device := lcdsim.NewDevice(geometry.Cols, geometry.Rows, 0, output)
+
+	return &lcdprocDevice{Device: device, client: client}, nil
+}
+
+func (lp *LCDproc) String() string {
+	return fmt.Sprintf("lcdproc://%s", lp.Addr)
+}
+
+// lcdprocDevice hangs up on LCDd along with the simulated device.
+type lcdprocDevice struct {
+	*lcdsim.Device
+	client *lcdproc.Client
+}
+
+func (ld *lcdprocDevice) Close() error {
+	ld.Device.Close()
+
+	return ld.client.Close()
+}
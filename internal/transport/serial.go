@@ -0,0 +1,184 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.bug.st/serial"
+)
+
+const (
+	resetPulse = 100 * time.Millisecond
+)
+
+var (
+	ErrInvalidFraming = errors.New("transport: error parsing serial framing, please specify data bits, parity and stop bits (e.g. 8N1, 7E2, 8O1.5)")
+	ErrReadTimeout    = errors.New("transport: error, device said nothing before the read timeout")
+)
+
+// SerialLine is how a serial port is set up when opened.
+type SerialLine struct {
+	BaudRate int
+	DataBits int
+	Parity   serial.Parity
+	StopBits serial.StopBits
+
+	// ReadTimeout drops the connection when the device says nothing for
+	// this long, 0 waits forever.
+	ReadTimeout time.Duration
+
+	// DTR and RTS are the levels the lines are set to at open. Arduinos
+	// reset when DTR is raised, keeping it low avoids that.
+	DTR bool
+	RTS bool
+	// Reset pulses DTR right after open, deliberately resetting the board.
+	Reset bool
+	// Settle is how long to wait after open before talking to the device,
+	// e.g. for the bootloader to hand over after a reset.
+	Settle time.Duration
+}
+
+// DefaultSerialLine is 8N1 with DTR and RTS raised, what opening a port
+// does without further setup.
+func DefaultSerialLine(baudRate int) SerialLine {
+	return SerialLine{
+		BaudRate: baudRate,
+		DataBits: 8,
+		Parity:   serial.NoParity,
+		StopBits: serial.OneStopBit,
+		DTR:      true,
+		RTS:      true,
+	}
+}
+
+var (
+	parities = map[byte]serial.Parity{
+		'N': serial.NoParity,
+		'O': serial.OddParity,
+		'E': serial.EvenParity,
+		'M': serial.MarkParity,
+		'S': serial.SpaceParity,
+	}
+
+	stopBits = map[string]serial.StopBits{
+		"1":   serial.OneStopBit,
+		"1.5": serial.OnePointFiveStopBits,
+		"2":   serial.TwoStopBits,
+	}
+)
+
+// SetFraming reads the usual data bits, parity, stop bits notation like
+// 8N1 into line.
+func (line *SerialLine) SetFraming(framing string) error {
+	if len(framing) < 3 || framing[0] < '5' || framing[0] > '8' {
+		return fmt.Errorf("%w: %s", ErrInvalidFraming, framing)
+	}
+
+	parity, ok := parities[framing[1]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidFraming, framing)
+	}
+
+	stop, ok := stopBits[framing[2:]]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrInvalidFraming, framing)
+	}
+
+	line.DataBits = int(framing[0] - '0')
+	line.Parity = parity
+	line.StopBits = stop
+
+	return nil
+}
+
+// Framing gives the line setup back in 8N1 notation.
+func (line SerialLine) Framing() string {
+	parity := byte('N')
+	for name, value := range parities {
+		if value == line.Parity {
+			parity = name
+		}
+	}
+
+	stop := "1"
+	for name, value := range stopBits {
+		if value == line.StopBits {
+			stop = name
+		}
+	}
+
+	return fmt.Sprintf("%d%c%s", line.DataBits, parity, stop)
+}
+
+func openSerial(name string, line SerialLine) (io.ReadWriteCloser, error) {
+	mode := &serial.Mode{
+		BaudRate: line.BaudRate,
+		DataBits: line.DataBits,
+		Parity:   line.Parity,
+		StopBits: line.StopBits,
+	}
+
+	// The lines are only touched when asked to, ports without modem
+	// lines like pseudo-terminals refuse it.
+	if !line.DTR || !line.RTS || line.Reset {
+		mode.InitialStatusBits = &serial.ModemOutputBits{
+			DTR: line.DTR && !line.Reset,
+			RTS: line.RTS,
+		}
+	}
+
+	port, err := serial.Open(name, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	if line.Reset {
+		time.Sleep(resetPulse)
+
+		if err := port.SetDTR(true); err != nil {
+			port.Close()
+			return nil, err
+		}
+	}
+
+	if line.ReadTimeout > 0 {
+		if err := port.SetReadTimeout(line.ReadTimeout); err != nil {
+			port.Close()
+			return nil, err
+		}
+	}
+
+	time.Sleep(line.Settle)
+
+	return &serialConn{Port: port}, nil
+}
+
+// serialConn turns the empty read a port gives on its read timeout into
+// ErrReadTimeout, so the connection is dropped instead of read again.
+type serialConn struct {
+	serial.Port
+}
+
+func (sc *serialConn) Read(p []byte) (int, error) {
+	n, err := sc.Port.Read(p)
+	if n == 0 && err == nil {
+		return 0, ErrReadTimeout
+	}
+
+	return n, err
+}
+
+type Serial struct {
+	Device string
+	Line   SerialLine
+}
+
+func (s *Serial) Open() (io.ReadWriteCloser, error) {
+	return openSerial(s.Device, s.Line)
+}
+
+func (s *Serial) String() string {
+	return "serial://" + s.Device
+}
@@ -0,0 +1,370 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+const (
+	minBackoff = 500 * time.Millisecond
+	maxBackoff = 10 * time.Second
+
+	// handshakeTimeout is how long legacy firmware that says nothing back
+	// to hello gets before it is taken as legacy.
+	handshakeTimeout = 2 * time.Second
+)
+
+var (
+	ErrDisconnected = errors.New("transport: error, display is disconnected")
+)
+
+type State int
+
+const (
+	StateDisconnected State = iota
+	StateConnecting
+	StateConnected
+)
+
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	}
+
+	return "disconnected"
+}
+
+// stickyCommands are remembered and sent again, in this order, after a
+// reconnect so the display comes back showing what it showed before.
+var stickyCommands = []string{
+	protocol.CmdBacklight,
+	protocol.CmdContrast,
+	protocol.CmdDisplay,
+}
+
+// Supervisor keeps a connection to the display up. It connects on first
+// use, and on EOF or I/O errors it reopens the transport with backoff
+// instead of handing the error to the reader.
+type Supervisor struct {
+	transport Transport
+	done      <-chan struct{}
+	closing   chan struct{}
+
+	// Handshake asks the device for its capabilities on every connect,
+	// without it the device is taken as legacy firmware.
+	Handshake bool
+	// Framed switches to the framed protocol when the device supports it.
+	Framed bool
+	// Push switches the device to push mode, where it no longer prompts
+	// and the host paces itself. There is no handshake in push mode.
+	Push bool
+	// Silent devices never prompt nor answer, like displays with another
+	// command set than the szb firmware. They get no handshake, and what
+	// they showed before a reconnect is up to the writer to send again.
+	Silent bool
+	// Init is written first on every connect.
+	Init []byte
+	// Flow guards writes, credits only work in push mode.
+	Flow Flow
+	// WriteTimeout drops the connection when a write, flow control wait
+	// included, takes longer. Flow control waits default to a second.
+	WriteTimeout  time.Duration
+	OnStateChange func(State, error)
+
+	mu     sync.Mutex
+	conn   io.ReadWriteCloser
+	state  State
+	caps   protocol.Capabilities
+	sticky map[string][]byte
+	closed bool
+
+	// owedPrompt is set when connecting used up a device prompt that no
+	// command answered, the reader still has to see it.
+	owedPrompt bool
+}
+
+func NewSupervisor(transport Transport, done <-chan struct{}) *Supervisor {
+	return &Supervisor{
+		transport: transport,
+		done:      done,
+		closing:   make(chan struct{}),
+		caps:      protocol.LegacyCapabilities,
+		sticky:    make(map[string][]byte),
+	}
+}
+
+// Connect blocks until the display is connected, then returns its
+// capabilities.
+func (sv *Supervisor) Connect() (protocol.Capabilities, error) {
+	if _, err := sv.connection(); err != nil {
+		return protocol.LegacyCapabilities, err
+	}
+
+	return sv.Capabilities(), nil
+}
+
+func (sv *Supervisor) Capabilities() protocol.Capabilities {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	return sv.caps
+}
+
+func (sv *Supervisor) State() State {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	return sv.state
+}
+
+func (sv *Supervisor) Read(p []byte) (int, error) {
+	for {
+		conn, err := sv.connection()
+		if err != nil {
+			return 0, err
+		}
+
+		sv.mu.Lock()
+		owedPrompt := sv.owedPrompt
+		sv.owedPrompt = false
+		sv.mu.Unlock()
+
+		if owedPrompt {
+			return copy(p, protocol.CmdPrompt+"\n"), nil
+		}
+
+		n, err := conn.Read(p)
+		if err == nil || n > 0 {
+			return n, nil
+		}
+
+		sv.drop(conn, err)
+	}
+}
+
+func (sv *Supervisor) Write(p []byte) (int, error) {
+	sv.mu.Lock()
+	sv.remember(p)
+	conn := sv.conn
+	sv.mu.Unlock()
+
+	if conn == nil {
+		return 0, ErrDisconnected
+	}
+
+	n, err := writeTimeout(conn, p, sv.WriteTimeout)
+	if err != nil {
+		sv.drop(conn, err)
+	}
+
+	return n, err
+}
+
+func (sv *Supervisor) Close() error {
+	sv.mu.Lock()
+	defer sv.mu.Unlock()
+
+	if sv.closed {
+		return nil
+	}
+
+	sv.closed = true
+	close(sv.closing)
+
+	var err error
+	if sv.conn != nil {
+		err = sv.conn.Close()
+	}
+
+	// Transports that wait for the display, like a listener, stop waiting.
+	if closer, ok := sv.transport.(io.Closer); ok {
+		err = errors.Join(err, closer.Close())
+	}
+
+	return err
+}
+
+func (sv *Supervisor) remember(cmd []byte) {
+	if bytes.HasPrefix(cmd, []byte(protocol.CmdClear)) {
+		delete(sv.sticky, protocol.CmdDisplay)
+		return
+	}
+
+	for _, prefix := range stickyCommands {
+		if bytes.HasPrefix(cmd, []byte(prefix)) {
+			sv.sticky[prefix] = bytes.Clone(cmd)
+			return
+		}
+	}
+}
+
+func (sv *Supervisor) connection() (io.ReadWriteCloser, error) {
+	sv.mu.Lock()
+	conn := sv.conn
+	sv.mu.Unlock()
+
+	if conn != nil {
+		return conn, nil
+	}
+
+	backoff := minBackoff
+
+	for {
+		sv.setState(StateConnecting, nil)
+
+		conn, err := sv.transport.Open()
+		if err == nil {
+			var (
+				caps       = protocol.LegacyCapabilities
+				owedPrompt bool
+			)
+
+			if sv.Flow == FlowRTSCTS {
+				conn, err = newCTSConn(conn, sv.flowTimeout())
+			}
+
+			if err == nil && len(sv.Init) > 0 {
+				_, err = conn.Write(sv.Init)
+			}
+
+			if err == nil && sv.Push && !sv.Silent {
+				conn, err = sv.pushConnection(conn)
+			}
+
+			if err == nil && sv.Handshake && !sv.Push && !sv.Silent {
+				caps, conn, err = protocol.Handshake(conn, handshakeTimeout)
+				owedPrompt = true
+			}
+
+			if err == nil && sv.Framed && caps.Framed {
+				_, err = conn.Write(protocol.FramedCommand())
+				owedPrompt = false
+				conn = protocol.NewFramedConn(conn)
+			}
+
+			if err == nil && !sv.Silent {
+				owedPrompt, err = sv.restore(conn, owedPrompt)
+			}
+
+			if err == nil {
+				sv.mu.Lock()
+				if sv.closed {
+					sv.mu.Unlock()
+					conn.Close()
+
+					return nil, io.EOF
+				}
+				sv.conn = conn
+				sv.caps = caps
+				sv.owedPrompt = owedPrompt
+				sv.mu.Unlock()
+
+				sv.setState(StateConnected, nil)
+
+				return conn, nil
+			}
+
+			conn.Close()
+		}
+
+		sv.setState(StateDisconnected, err)
+
+		select {
+		case <-sv.done:
+			return nil, io.EOF
+		case <-sv.closing:
+			return nil, io.EOF
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+func (sv *Supervisor) flowTimeout() time.Duration {
+	if sv.WriteTimeout > 0 {
+		return sv.WriteTimeout
+	}
+
+	return defaultFlowTimeout
+}
+
+// pushConnection switches a fresh connection to push mode and puts the
+// flow control in front of it.
+func (sv *Supervisor) pushConnection(conn io.ReadWriteCloser) (io.ReadWriteCloser, error) {
+	if _, err := conn.Write(protocol.PushCommand()); err != nil {
+		return conn, err
+	}
+
+	if sv.Flow == FlowCredit {
+		credit := protocol.NewCreditConn(conn)
+		credit.Timeout = sv.flowTimeout()
+
+		return credit, nil
+	}
+
+	return conn, nil
+}
+
+// restore sends the remembered commands to a fresh connection, one per
+// device prompt like the main loop does, or right away in push mode. It
+// reports whether a prompt is still owed to the reader.
+func (sv *Supervisor) restore(conn io.ReadWriter, owedPrompt bool) (bool, error) {
+	sv.mu.Lock()
+	cmds := [][]byte{}
+	for _, prefix := range stickyCommands {
+		if cmd, ok := sv.sticky[prefix]; ok {
+			cmds = append(cmds, cmd)
+		}
+	}
+	sv.mu.Unlock()
+
+	for _, cmd := range cmds {
+		if !owedPrompt && !sv.Push {
+			if err := protocol.WaitPrompt(conn); err != nil {
+				return false, err
+			}
+		}
+
+		if _, err := conn.Write(cmd); err != nil {
+			return false, err
+		}
+
+		owedPrompt = false
+	}
+
+	return owedPrompt, nil
+}
+
+func (sv *Supervisor) drop(conn io.ReadWriteCloser, err error) {
+	sv.mu.Lock()
+	if sv.conn != conn {
+		sv.mu.Unlock()
+		return
+	}
+
+	sv.conn = nil
+	sv.mu.Unlock()
+
+	conn.Close()
+	sv.setState(StateDisconnected, err)
+}
+
+func (sv *Supervisor) setState(state State, err error) {
+	sv.mu.Lock()
+	changed := sv.state != state
+	sv.state = state
+	sv.mu.Unlock()
+
+	if (changed || err != nil) && sv.OnStateChange != nil {
+		sv.OnStateChange(state, err)
+	}
+}
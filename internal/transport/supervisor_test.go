@@ -0,0 +1,255 @@
+package transport
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fudanchii/szb/internal/display"
+	"github.com/fudanchii/szb/internal/driver"
+	"github.com/fudanchii/szb/internal/lcdsim"
+	"github.com/fudanchii/szb/internal/protocol"
+)
+
+type blankRenderer struct{}
+
+func (blankRenderer) Render([]byte) error    { return nil }
+func (blankRenderer) Clear() error           { return nil }
+func (blankRenderer) SetBacklight(int) error { return nil }
+
+// flakyTransport fails its first open, then hands out simulated devices.
+type flakyTransport struct {
+	mu      sync.Mutex
+	opens   int
+	devices []*lcdsim.Device
+}
+
+func (ft *flakyTransport) Open() (io.ReadWriteCloser, error) {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	ft.opens++
+	if ft.opens == 1 {
+		return nil, errors.New("not plugged in yet")
+	}
+
+	dev := lcdsim.NewDevice(20, 4, time.Millisecond, blankRenderer{})
+	ft.devices = append(ft.devices, dev)
+
+	return dev, nil
+}
+
+func (ft *flakyTransport) String() string {
+	return "flaky:"
+}
+
+func (ft *flakyTransport) device(idx int) *lcdsim.Device {
+	ft.mu.Lock()
+	defer ft.mu.Unlock()
+
+	return ft.devices[idx]
+}
+
+func TestSupervisorReconnects(t *testing.T) {
+	ft := &flakyTransport{}
+	sv := NewSupervisor(ft, make(chan struct{}))
+	sv.Handshake = true
+	defer sv.Close()
+
+	scanner := bufio.NewScanner(sv)
+	scanner.Split(bufio.ScanWords)
+
+	frame := bytes.Repeat([]byte{'x'}, 80)
+
+	if !scanner.Scan() || scanner.Text() != protocol.CmdPrompt {
+		t.Fatalf("expecting a prompt, got %q", scanner.Text())
+	}
+
+	if _, err := sv.Write(protocol.DisplayCommand(frame)); err != nil {
+		t.Fatal(err)
+	}
+
+	if sv.State() != StateConnected {
+		t.Fatalf("state = %s, want connected", sv.State())
+	}
+
+	// Unplug, the next read has to come back through a new device that
+	// already shows the last frame.
+	ft.device(0).Close()
+
+	if !scanner.Scan() || scanner.Text() != protocol.CmdPrompt {
+		t.Fatalf("expecting a prompt after reconnect, got %q", scanner.Text())
+	}
+
+	for _, row := range ft.device(1).Screen() {
+		if !bytes.Equal(row, frame[:20]) {
+			t.Fatalf("frame was not restored, row is %q", row)
+		}
+	}
+}
+
+type deviceTransport struct {
+	dev *lcdsim.Device
+}
+
+func (dt deviceTransport) Open() (io.ReadWriteCloser, error) {
+	return dt.dev, nil
+}
+
+func (dt deviceTransport) String() string {
+	return "device:"
+}
+
+func TestSupervisorPushCredit(t *testing.T) {
+	// The device never hands credits back within the test.
+	dev := lcdsim.NewDevice(20, 4, time.Hour, blankRenderer{})
+
+	sv := NewSupervisor(deviceTransport{dev}, make(chan struct{}))
+	sv.Push = true
+	sv.Flow = FlowCredit
+	sv.WriteTimeout = 20 * time.Millisecond
+	defer sv.Close()
+
+	if _, err := sv.Connect(); err != nil {
+		t.Fatal(err)
+	}
+
+	frame := bytes.Repeat([]byte{'x'}, 80)
+
+	for range lcdsim.PushWindow {
+		if _, err := sv.Write(protocol.DisplayCommand(frame)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Either the credit wait or the write itself times out first.
+	_, err := sv.Write(protocol.DisplayCommand(frame))
+	if !errors.Is(err, protocol.ErrNoCredit) && !errors.Is(err, ErrWriteTimeout) {
+		t.Fatalf("write past the window returned %v, want a timeout", err)
+	}
+
+	if sv.State() != StateDisconnected {
+		t.Errorf("state = %s, want disconnected after running out of credit", sv.State())
+	}
+}
+
+// lineRecorder keeps what a command-set driver writes.
+type lineRecorder struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (lr *lineRecorder) Open() (io.ReadWriteCloser, error) {
+	return lr, nil
+}
+
+func (lr *lineRecorder) String() string {
+	return "recorder"
+}
+
+func (lr *lineRecorder) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+func (lr *lineRecorder) Write(p []byte) (int, error) {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	return lr.buf.Write(p)
+}
+
+func (lr *lineRecorder) Close() error {
+	return nil
+}
+
+func (lr *lineRecorder) written() []byte {
+	lr.mu.Lock()
+	defer lr.mu.Unlock()
+
+	return bytes.Clone(lr.buf.Bytes())
+}
+
+func TestSupervisorSilent(t *testing.T) {
+	line := &lineRecorder{}
+	cf := driver.NewCrystalfontz(display.Geometry{Cols: 16, Rows: 2})
+
+	// Asked for a handshake and push mode, a silent device gets neither.
+	sv := NewSupervisor(line, make(chan struct{}))
+	sv.Silent = true
+	sv.Handshake = true
+	sv.Push = true
+	sv.Init = cf.Init()
+	defer sv.Close()
+
+	caps, err := sv.Connect()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caps != protocol.LegacyCapabilities {
+		t.Errorf("caps = %+v, want legacy", caps)
+	}
+
+	frame := []byte(fmt.Sprintf("%-16s%-16s", "hello", "world"))
+	if _, err := sv.Write(cf.Frame(frame)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := slices.Concat(
+		[]byte{0x04, 0x14, 0x18, 0x0c},
+		[]byte{0x11, 0, 0}, frame[:16],
+		[]byte{0x11, 0, 1}, frame[16:],
+	)
+	if got := line.written(); !bytes.Equal(got, want) {
+		t.Errorf("wrote % x\nwant  % x", got, want)
+	}
+}
+
+func TestSupervisorCloseStopsListening(t *testing.T) {
+	server := &TCPServer{Addr: "127.0.0.1:0"}
+	sv := NewSupervisor(server, make(chan struct{}))
+
+	connected := make(chan error, 1)
+	go func() {
+		_, err := sv.Connect()
+		connected <- err
+	}()
+
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		server.mu.Lock()
+		listening := server.listener != nil
+		server.mu.Unlock()
+
+		if listening {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("the server never listened")
+		}
+	}
+
+	if err := sv.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-connected:
+		if !errors.Is(err, io.EOF) {
+			t.Errorf("Connect = %v, want EOF", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Connect still waits for a display after Close")
+	}
+
+	if _, err := server.Open(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Open after Close = %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fudanchii/szb/internal/lcdsim"
+)
+
+var (
+	ErrUnknownScheme = errors.New("transport: error, unknown scheme, use serial://, usb:, tcp://, tcp-listen://, unix://, lcdproc://, stdio: or a device path")
+)
+
+// Transport knows how to reach a display, every Open gives a fresh
+// connection speaking the szb protocol.
+type Transport interface {
+	Open() (io.ReadWriteCloser, error)
+	String() string
+}
+
+// Parse picks a transport from target. Plain paths are taken as serial
+// devices so the old `-c /dev/ttyACM0` keeps working. Serial and USB
+// ports are set up as line says.
+func Parse(target string, line SerialLine) (Transport, error) {
+	scheme, rest, found := strings.Cut(target, ":")
+	if !found || strings.HasPrefix(target, "/") {
+		return &Serial{Device: target, Line: line}, nil
+	}
+
+	addr := strings.TrimPrefix(rest, "//")
+
+	switch scheme {
+	case "serial":
+		return &Serial{Device: addr, Line: line}, nil
+	case "usb":
+		match, err := ParseUSBMatch(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		return &USB{Match: match, Line: line}, nil
+	case "tcp":
+		return &TCPClient{Addr: addr}, nil
+	case "tcp-listen":
+		return &TCPServer{Addr: addr}, nil
+	case "unix":
+		return &Unix{Path: addr}, nil
+	case "lcdproc":
+		return &LCDproc{Addr: addr}, nil
+	case "stdio":
+		return &Stdio{}, nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrUnknownScheme, scheme)
+}
+
+type TCPClient struct {
+	Addr string
+}
+
+func (tc *TCPClient) Open() (io.ReadWriteCloser, error) {
+	return net.Dial("tcp", tc.Addr)
+}
+
+func (tc *TCPClient) String() string {
+	return "tcp://" + tc.Addr
+}
+
+// TCPServer waits for the display to connect in. The listener stays up
+// across connections so a display can come back after dropping off.
+type TCPServer struct {
+	Addr string
+
+	mu       sync.Mutex
+	listener net.Listener
+	closed   bool
+}
+
+func (ts *TCPServer) Open() (io.ReadWriteCloser, error) {
+	ts.mu.Lock()
+	if ts.closed {
+		ts.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+
+	if ts.listener == nil {
+		listener, err := net.Listen("tcp", ts.Addr)
+		if err != nil {
+			ts.mu.Unlock()
+			return nil, err
+		}
+
+		ts.listener = listener
+	}
+	listener := ts.listener
+	ts.mu.Unlock()
+
+	return listener.Accept()
+}
+
+// Close stops listening, a display being waited for is not anymore.
+func (ts *TCPServer) Close() error {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	ts.closed = true
+
+	if ts.listener == nil {
+		return nil
+	}
+
+	return ts.listener.Close()
+}
+
+func (ts *TCPServer) String() string {
+	return "tcp-listen://" + ts.Addr
+}
+
+type Unix struct {
+	Path string
+}
+
+func (u *Unix) Open() (io.ReadWriteCloser, error) {
+	return net.Dial("unix", u.Path)
+}
+
+func (u *Unix) String() string {
+	return "unix://" + u.Path
+}
+
+// Stdio speaks the protocol over stdin and stdout, e.g. behind socat or ssh.
+type Stdio struct{}
+
+func (Stdio) Open() (io.ReadWriteCloser, error) {
+	return stdio{}, nil
+}
+
+func (Stdio) String() string {
+	return "stdio:"
+}
+
+type stdio struct{}
+
+func (stdio) Read(p []byte) (int, error) {
+	return os.Stdin.Read(p)
+}
+
+func (stdio) Write(p []byte) (int, error) {
+	return os.Stdout.Write(p)
+}
+
+func (stdio) Close() error {
+	return nil
+}
+
+// Simulator renders to a terminal through lcdsim instead of a real display.
+type Simulator struct {
+	Cols, Rows int
+	Latency    time.Duration
+	Out        io.Writer
+}
+
+func (sim *Simulator) Open() (io.ReadWriteCloser, error) {
+	return lcdsim.NewDevice(sim.Cols, sim.Rows, sim.Latency, lcdsim.NewTerminal(sim.Out, sim.Cols, sim.Rows)), nil
+}
+
+func (sim *Simulator) String() string {
+	return "sim:"
+}
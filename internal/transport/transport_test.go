@@ -0,0 +1,98 @@
+package transport
+
+import (
+	"errors"
+	"testing"
+
+	"go.bug.st/serial/enumerator"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		target string
+		want   string
+	}{
+		{"/dev/ttyACM0", "serial:///dev/ttyACM0"},
+		{"COM3", "serial://COM3"},
+		{"serial:///dev/ttyUSB1", "serial:///dev/ttyUSB1"},
+		{"usb:2341:0043", "usb:vid=2341,pid=0043"},
+		{"usb:serial=7523031383335,product=arduino uno", "usb:serial=7523031383335,product=arduino uno"},
+		{"tcp://10.0.0.5:7000", "tcp://10.0.0.5:7000"},
+		{"tcp-listen://:7000", "tcp-listen://:7000"},
+		{"unix:///run/szb.sock", "unix:///run/szb.sock"},
+		{"lcdproc://localhost:13666", "lcdproc://localhost:13666"},
+		{"stdio:", "stdio:"},
+	}
+
+	for _, c := range cases {
+		tr, err := Parse(c.target, DefaultSerialLine(115200))
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", c.target, err)
+		}
+
+		if got := tr.String(); got != c.want {
+			t.Errorf("Parse(%q) = %s, want %s", c.target, got, c.want)
+		}
+	}
+
+	if _, err := Parse("http://example.com", DefaultSerialLine(115200)); !errors.Is(err, ErrUnknownScheme) {
+		t.Errorf("Parse with unknown scheme returned %v", err)
+	}
+}
+
+func TestUSBMatch(t *testing.T) {
+	port := &enumerator.PortDetails{
+		Name:         "/dev/ttyACM1",
+		IsUSB:        true,
+		VID:          "2341",
+		PID:          "0043",
+		SerialNumber: "7523031383335",
+		Product:      "Arduino Uno",
+	}
+
+	cases := []struct {
+		spec string
+		want bool
+	}{
+		{"2341:0043", true},
+		{"2341:0042", false},
+		{"serial=7523031383335", true},
+		{"product=uno", true},
+		{"vid=2341,product=leonardo", false},
+	}
+
+	for _, c := range cases {
+		match, err := ParseUSBMatch(c.spec)
+		if err != nil {
+			t.Fatalf("ParseUSBMatch(%q): %v", c.spec, err)
+		}
+
+		if got := match.Matches(port); got != c.want {
+			t.Errorf("%q matches = %v, want %v", c.spec, got, c.want)
+		}
+	}
+
+	if _, err := ParseUSBMatch("model=uno"); !errors.Is(err, ErrInvalidUSBMatch) {
+		t.Errorf("ParseUSBMatch with unknown key returned %v", err)
+	}
+}
+
+func TestSetFraming(t *testing.T) {
+	for _, framing := range []string{"8N1", "7E2", "5O1.5", "8M1", "6S2"} {
+		line := DefaultSerialLine(9600)
+		if err := line.SetFraming(framing); err != nil {
+			t.Fatalf("SetFraming(%q): %v", framing, err)
+		}
+
+		if got := line.Framing(); got != framing {
+			t.Errorf("SetFraming(%q) reads back as %s", framing, got)
+		}
+	}
+
+	for _, framing := range []string{"", "9N1", "8X1", "8N3", "8N"} {
+		line := DefaultSerialLine(9600)
+		if err := line.SetFraming(framing); !errors.Is(err, ErrInvalidFraming) {
+			t.Errorf("SetFraming(%q) returned %v, want ErrInvalidFraming", framing, err)
+		}
+	}
+}
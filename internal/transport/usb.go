@@ -0,0 +1,137 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"go.bug.st/serial/enumerator"
+)
+
+var (
+	ErrNoMatchingPort  = errors.New("transport: error, no serial port matches")
+	ErrInvalidUSBMatch = errors.New("config: error parsing usb match, please specify VID:PID or comma separated key=value with keys vid, pid, serial, product (e.g. usb:vid=2341,serial=7523)")
+)
+
+// USBMatch selects a serial port by what the USB device reports about
+// itself, empty fields match anything.
+type USBMatch struct {
+	VID          string
+	PID          string
+	SerialNumber string
+	Product      string
+}
+
+func ParseUSBMatch(spec string) (USBMatch, error) {
+	var match USBMatch
+
+	if vid, pid, ok := strings.Cut(spec, ":"); ok && !strings.Contains(spec, "=") {
+		return USBMatch{VID: vid, PID: pid}, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			return match, ErrInvalidUSBMatch
+		}
+
+		switch key {
+		case "vid":
+			match.VID = value
+		case "pid":
+			match.PID = value
+		case "serial":
+			match.SerialNumber = value
+		case "product":
+			match.Product = value
+		default:
+			return match, ErrInvalidUSBMatch
+		}
+	}
+
+	if match == (USBMatch{}) {
+		return match, ErrInvalidUSBMatch
+	}
+
+	return match, nil
+}
+
+func (m USBMatch) Matches(port *enumerator.PortDetails) bool {
+	if !port.IsUSB {
+		return false
+	}
+
+	return (m.VID == "" || strings.EqualFold(m.VID, port.VID)) &&
+		(m.PID == "" || strings.EqualFold(m.PID, port.PID)) &&
+		(m.SerialNumber == "" || m.SerialNumber == port.SerialNumber) &&
+		(m.Product == "" || strings.Contains(strings.ToLower(port.Product), strings.ToLower(m.Product)))
+}
+
+func (m USBMatch) String() string {
+	fields := []string{}
+
+	for _, field := range [][2]string{
+		{"vid", m.VID},
+		{"pid", m.PID},
+		{"serial", m.SerialNumber},
+		{"product", m.Product},
+	} {
+		if field[1] != "" {
+			fields = append(fields, field[0]+"="+field[1])
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// ListPorts returns every serial port the OS knows about, sorted by name.
+func ListPorts() ([]*enumerator.PortDetails, error) {
+	ports, err := enumerator.GetDetailedPortsList()
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(ports, func(a, b *enumerator.PortDetails) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return ports, nil
+}
+
+// USB looks the port up on every Open, so the display is found again even
+// when it comes back under another device name.
+type USB struct {
+	Match USBMatch
+	Line  SerialLine
+}
+
+func (u *USB) Open() (io.ReadWriteCloser, error) {
+	name, err := u.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	return openSerial(name, u.Line)
+}
+
+// Resolve returns the device name of the first port that matches.
+func (u *USB) Resolve() (string, error) {
+	ports, err := ListPorts()
+	if err != nil {
+		return "", err
+	}
+
+	for _, port := range ports {
+		if u.Match.Matches(port) {
+			return port.Name, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrNoMatchingPort, u.Match)
+}
+
+func (u *USB) String() string {
+	return "usb:" + u.Match.String()
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sync"
 	"time"
 
 	owm "github.com/briandowns/openweathermap"
@@ -12,8 +13,12 @@ import (
 var apiKey = os.Getenv("OWM_API_KEY")
 
 type Stats struct {
+	mu            sync.Mutex
 	current       *owm.CurrentWeatherData
 	nowDisplaying string
+	coordinate    *owm.Coordinates
+
+	refresh chan struct{}
 }
 
 func NewStats(coordinate *owm.Coordinates) (*Stats, error) {
@@ -21,35 +26,41 @@ func NewStats(coordinate *owm.Coordinates) (*Stats, error) {
 		return nil, errors.New("OWM_API_KEY is empty.")
 	}
 
-	weatherer, err := owm.NewCurrent("C", "en", apiKey)
-	if err != nil {
-		return nil, err
+	stats := &Stats{
+		nowDisplaying: "desc",
+		coordinate:    coordinate,
+		refresh:       make(chan struct{}, 1),
 	}
 
-	if err := weatherer.CurrentByCoordinates(coordinate); err != nil {
+	if err := stats.fetch(); err != nil {
 		return nil, err
 	}
 
-	stats := &Stats{current: weatherer, nowDisplaying: "desc"}
-
 	go func(stats *Stats) {
 		fiveMinutes := 5 * 60
 		counter := 0
 		for {
-			time.Sleep(10 * time.Second)
+			select {
+			case <-time.After(10 * time.Second):
+			case <-stats.refresh:
+				if err := stats.fetch(); err != nil {
+					fmt.Fprintf(os.Stderr, "weather: %v\n", err)
+				}
+
+				continue
+			}
 
 			if counter%30 == 0 {
-				stats.nowDisplaying = "desc"
+				stats.show("desc")
 			}
 
 			if counter%50 == 0 {
-				stats.nowDisplaying = "temp"
+				stats.show("temp")
 			}
 
 			if counter == fiveMinutes {
-				err := weatherer.CurrentByCoordinates(coordinate)
-				if err != nil {
-					fmt.Println(err)
+				if err := stats.fetch(); err != nil {
+					fmt.Fprintf(os.Stderr, "weather: %v\n", err)
 				}
 
 				counter = 0
@@ -64,14 +75,100 @@ func NewStats(coordinate *owm.Coordinates) (*Stats, error) {
 	return stats, nil
 }
 
+// fetch reads the current weather into a fresh value and swaps it in, so
+// readers never see one half written.
+func (s *Stats) fetch() error {
+	current, err := owm.NewCurrent("C", "en", apiKey)
+	if err != nil {
+		return err
+	}
+
+	if err := current.CurrentByCoordinates(s.Coordinates()); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.current = current
+	s.mu.Unlock()
+
+	return nil
+}
+
+func (s *Stats) show(what string) {
+	s.mu.Lock()
+	s.nowDisplaying = what
+	s.mu.Unlock()
+}
+
+// snapshot returns the current weather and what to show of it, the
+// weather is replaced as a whole and never written to after.
+func (s *Stats) snapshot() (*owm.CurrentWeatherData, string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.current, s.nowDisplaying
+}
+
+func (s *Stats) Coordinates() *owm.Coordinates {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.coordinate
+}
+
+// SetCoordinates moves the weather location, the current weather is
+// fetched again right away.
+func (s *Stats) SetCoordinates(coordinate *owm.Coordinates) {
+	s.mu.Lock()
+	s.coordinate = coordinate
+	s.mu.Unlock()
+
+	select {
+	case s.refresh <- struct{}{}:
+	default:
+	}
+}
+
+// Condition is what the weather is like, in broad strokes.
+type Condition string
+
+const (
+	ConditionClear  Condition = "clear"
+	ConditionClouds Condition = "clouds"
+	ConditionRain   Condition = "rain"
+	ConditionSnow   Condition = "snow"
+)
+
+// Condition sums the current weather up, clouds when there is nothing to
+// go by.
+func (s *Stats) Condition() Condition {
+	current, _ := s.snapshot()
+	if len(current.Weather) == 0 {
+		return ConditionClouds
+	}
+
+	switch current.Weather[0].Main {
+	case "Clear":
+		return ConditionClear
+	case "Rain", "Drizzle", "Thunderstorm":
+		return ConditionRain
+	case "Snow":
+		return ConditionSnow
+	}
+
+	return ConditionClouds
+}
+
 func (s *Stats) String() string {
-	switch s.nowDisplaying {
+	current, nowDisplaying := s.snapshot()
+
+	switch nowDisplaying {
 	case "desc":
-		desc := s.current.Weather[0].Description
+		desc := current.Weather[0].Description
 		descLen := len(desc)
 		return fmt.Sprintf("%*s", 20, fmt.Sprintf("%*s", -(((20-descLen)/2)+descLen), desc))
 	case "temp":
-		temp := fmt.Sprintf("%.1fºC", s.current.Main.Temp)
+		temp := fmt.Sprintf("%.1fºC", current.Main.Temp)
 		tempLen := len(temp)
 		return fmt.Sprintf("%*s", 20, fmt.Sprintf("%*s", -(((20-tempLen)/2)+tempLen), temp))
 	}